@@ -2,6 +2,7 @@ package cmdgo
 
 import (
 	"errors"
+	"sort"
 	"strings"
 )
 
@@ -68,6 +69,20 @@ func (pc PromptChoices) Convert(input string) (string, error) {
 	return "", ErrInvalidConversion
 }
 
+// Returns the text of each choice in sorted order. If a choice has no text its key is used.
+func (pc PromptChoices) Texts() []string {
+	texts := make([]string, 0, len(pc))
+	for key, choice := range pc {
+		if choice.Text != "" {
+			texts = append(texts, choice.Text)
+		} else {
+			texts = append(texts, key)
+		}
+	}
+	sort.Strings(texts)
+	return texts
+}
+
 // Returns whether there are any choices defined.
 func (pc PromptChoices) HasChoices() bool {
 	return len(pc) > 0
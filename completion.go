@@ -0,0 +1,766 @@
+package cmdgo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// An error returned when a completion script is requested for a shell that is not supported.
+var ErrUnsupportedShell = errors.New("unsupported shell, expected bash, zsh, or fish")
+
+// The name of the hidden command which prints the completion candidates for the given arguments.
+const CompleteCommandName = "__complete"
+
+// The line printed by the complete command when the shell should complete file paths.
+const CompleteFilesDirective = ":files"
+
+// A command which prints a completion script for the registry executing it.
+// ex: myprogram completion bash
+type CompletionCommand struct {
+	Shell   string `prompt:"Shell" pos:"1" help:"The shell to generate a completion script for." options:"bash,zsh,fish"`
+	Program string `prompt:"-" help:"The name of the program to complete, defaults to the running program."`
+	Dynamic bool   `prompt:"-" help:"If the script should ask the program for completions, allowing completions only known at runtime."`
+}
+
+var _ Executable = &CompletionCommand{}
+
+func (cmd *CompletionCommand) Execute(opts *Options) error {
+	registry := opts.Registry()
+	if registry == nil {
+		return ErrNoCommand
+	}
+	program := cmd.Program
+	if program == "" {
+		program = opts.ProgramName
+	}
+	var script string
+	var err error
+	if cmd.Dynamic {
+		script, err = registry.CompletionDynamic(cmd.Shell, program)
+	} else {
+		script, err = registry.Completion(opts, cmd.Shell, program)
+	}
+	if err != nil {
+		return err
+	}
+	return opts.Printf("%s", script)
+}
+
+// A hidden command which prints the completion candidates for the word being completed, one per line.
+// The arguments are the words after the program name, the last being the word being completed.
+// ex: myprogram __complete echo --m
+type CompleteCommand struct{}
+
+var _ Executable = &CompleteCommand{}
+
+func (cmd *CompleteCommand) Execute(opts *Options) error {
+	registry := opts.Registry()
+	if registry == nil {
+		return ErrNoCommand
+	}
+	completions, err := registry.Complete(opts, opts.Args)
+	if err != nil {
+		return err
+	}
+	for _, candidate := range completions.Candidates {
+		err = opts.Printf("%s\n", candidate)
+		if err != nil {
+			return err
+		}
+	}
+	if completions.Files {
+		return opts.Printf("%s\n", CompleteFilesDirective)
+	}
+	return nil
+}
+
+// Adds the "completion" command to the registry which prints a completion script for the given shell,
+// and the hidden "__complete" command which dynamic completion scripts invoke.
+func (r *Registry) AddCompletion() {
+	r.Add(Entry{
+		Name:      "completion",
+		HelpShort: "Prints a completion script for bash, zsh, or fish.",
+		HelpLong:  "Prints a completion script for bash, zsh, or fish. ex: source <(myprogram completion bash)",
+		Command:   CompletionCommand{},
+	})
+	r.Add(Entry{
+		Name:    CompleteCommandName,
+		Command: CompleteCommand{},
+		Hidden:  true,
+		RawArgs: true,
+	})
+}
+
+// Generates a completion script for the given shell and program name. The script completes
+// command names & aliases, argument names (including nested struct, slice, array, and map arguments),
+// and the values of arguments that have choices.
+func (r Registry) Completion(opts *Options, shell string, program string) (string, error) {
+	if program == "" {
+		program = filepath.Base(os.Args[0])
+	}
+
+	root, err := r.completionNode(opts, "", nil)
+	if err != nil {
+		return "", err
+	}
+
+	gen := completionGenerator{
+		program:  program,
+		function: completionFunction(program),
+		root:     root,
+	}
+
+	switch strings.ToLower(shell) {
+	case "bash":
+		return gen.bash(), nil
+	case "zsh":
+		return gen.zsh(), nil
+	case "fish":
+		return gen.fish(), nil
+	}
+
+	return "", ErrUnsupportedShell
+}
+
+// Generates a completion script for the given shell and program name which invokes the
+// hidden "__complete" command of the program to get the candidates. See Registry.Complete.
+func (r Registry) CompletionDynamic(shell string, program string) (string, error) {
+	if program == "" {
+		program = filepath.Base(os.Args[0])
+	}
+
+	gen := completionGenerator{
+		program:  program,
+		function: completionFunction(program),
+	}
+
+	switch strings.ToLower(shell) {
+	case "bash":
+		return gen.bashDynamic(), nil
+	case "zsh":
+		return gen.zshDynamic(), nil
+	case "fish":
+		return gen.fishDynamic(), nil
+	}
+
+	return "", ErrUnsupportedShell
+}
+
+// The candidates for the word being completed.
+type Completions struct {
+	// The words which can complete the current word.
+	Candidates []string
+	// If the shell should complete file paths.
+	Files bool
+}
+
+// Returns the completion candidates given the words after the program name, the last
+// word being the one completed. Command names are completed until a command is found, then
+// the command is captured from the words given (without prompting) and the argument names
+// or argument values are completed. Values come from HasCompletions, HasChoices, or options.
+func (r Registry) Complete(opts *Options, words []string) (Completions, error) {
+	completions := Completions{
+		Candidates: make([]string, 0),
+	}
+
+	current := ""
+	if len(words) > 0 {
+		current = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	var entry *Entry
+	depth := 0
+	if len(words) > 0 {
+		entry, depth = r.EntryForDeep(words)
+	}
+
+	if entry == nil {
+		candidates := make([]string, 0)
+		registry := r.subAt(words)
+		if !registry.IsEmpty() {
+			candidates = append([]string{opts.ArgPrefix + "help"}, registry.names()...)
+		}
+		completions.Candidates = filterCompletions(candidates, current)
+		return completions, nil
+	}
+
+	if entry.Command == nil {
+		return completions, nil
+	}
+
+	args := words[depth+1:]
+	command := cloneDefault(entry.Command)
+	instance := GetInstance(command)
+
+	argsNow, argsOriginal, disablePrompt, forcePrompt := opts.Args, opts.ArgsOriginal, opts.DisablePrompt, opts.ForcePrompt
+	opts.Args = append([]string{}, args...)
+	opts.ArgsOriginal = args
+	opts.DisablePrompt = true
+	opts.ForcePrompt = false
+	// Capturing is best-effort, the words being completed are often incomplete or invalid so the error is ignored
+	// and the values which could be captured are used for the completions which depend on them.
+	instance.Capture(opts)
+	opts.Args, opts.ArgsOriginal, opts.DisablePrompt, opts.ForcePrompt = argsNow, argsOriginal, disablePrompt, forcePrompt
+
+	infos, err := getArgInfos(opts, instance)
+	if err != nil {
+		return completions, err
+	}
+
+	if len(args) > 0 {
+		previous := args[len(args)-1]
+		if strings.HasPrefix(strings.ToLower(previous), strings.ToLower(opts.ArgPrefix)) {
+			previousKey := Normalize(previous[len(opts.ArgPrefix):])
+
+			if opts.Importer(previousKey) != nil || opts.exportArg(previousKey) != nil {
+				completions.Files = true
+				return completions, nil
+			}
+			if previousKey == "interactive" {
+				completions.Candidates = filterCompletions([]string{"true", "false"}, current)
+				return completions, nil
+			}
+			for _, info := range infos {
+				if Normalize(info.Arg) == Normalize(previous) && !info.Prop.IsBool() {
+					completions.Candidates = filterCompletions(info.Prop.GetCompletions(opts, current), current)
+					return completions, nil
+				}
+			}
+		}
+	}
+
+	if !strings.HasPrefix(strings.ToLower(current), strings.ToLower(opts.ArgPrefix)) {
+		opts.Args = append([]string{}, args...)
+		positionals, err := GetInstance(cloneDefault(entry.Command)).takePositionals(opts)
+		opts.Args = argsNow
+		if err != nil {
+			return completions, err
+		}
+
+		next := len(positionals.values) + 1
+		for _, prop := range instance.PropertyList {
+			if prop.Pos == next || (prop.PosRest && next > positionals.last) {
+				values := filterCompletions(prop.GetCompletions(opts, current), current)
+				if len(values) > 0 {
+					completions.Candidates = values
+					return completions, nil
+				}
+			}
+		}
+	}
+
+	candidates := []string{opts.ArgPrefix + "help", opts.ArgPrefix + "interactive"}
+	if explain := opts.explainArg(infos); explain != "" {
+		candidates = append(candidates, explain)
+	}
+	for _, importer := range opts.importers() {
+		candidates = append(candidates, opts.ArgPrefix+importer.Name)
+	}
+	for _, exporter := range opts.exporters() {
+		candidates = append(candidates, opts.ArgPrefix+opts.ArgExportPrefix+exporter.Name)
+	}
+	for _, info := range infos {
+		candidates = append(candidates, info.Arg)
+	}
+	completions.Candidates = filterCompletions(candidates, current)
+
+	return completions, nil
+}
+
+// Returns the candidates which start with the given partial text, ignoring case.
+func filterCompletions(candidates []string, partial string) []string {
+	filtered := make([]string, 0, len(candidates))
+	lowerPartial := strings.ToLower(partial)
+	for _, candidate := range candidates {
+		if strings.HasPrefix(strings.ToLower(candidate), lowerPartial) {
+			filtered = append(filtered, candidate)
+		}
+	}
+	return filtered
+}
+
+// Returns the name of the shell function for completing the given program.
+func completionFunction(program string) string {
+	return "_" + completionIdentifier.ReplaceAllString(program, "_")
+}
+
+var completionIdentifier = regexp.MustCompile("[^a-zA-Z0-9_]")
+
+// A command or registry of commands that can be completed.
+type completionNode struct {
+	// The path of normalized names to this node, ex: "/sub/simple"
+	Path string
+	// The names and aliases which lead to this node from its parent.
+	Words []string
+	// The sub commands of this node.
+	Children []*completionNode
+	// The arguments the command of this node accepts.
+	Args []completionArg
+	// The acceptable values of the positional arguments the command of this node accepts.
+	Positionals []string
+}
+
+// An argument that can be completed.
+type completionArg struct {
+	// The full argument, ex: "--movies-1-title"
+	Arg string
+	// The acceptable values for the argument.
+	Values []string
+	// If the argument value is a path to a file.
+	Files bool
+}
+
+func (r Registry) completionNode(opts *Options, path string, words []string) (*completionNode, error) {
+	node := &completionNode{
+		Path:     path,
+		Words:    words,
+		Children: make([]*completionNode, 0),
+		Args:     []completionArg{{Arg: opts.ArgPrefix + "help"}},
+	}
+
+	for _, entry := range r.entries {
+		childWords := entry.names()
+		if len(childWords) == 0 || entry.Hidden {
+			continue
+		}
+		childPath := path + "/" + Normalize(entry.Name)
+
+		var child *completionNode
+		var err error
+
+		if !entry.Sub.IsEmpty() {
+			child, err = entry.Sub.completionNode(opts, childPath, childWords)
+		} else {
+			child, err = entry.completionNode(opts, childPath, childWords)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		node.Children = append(node.Children, child)
+	}
+
+	return node, nil
+}
+
+func (entry Entry) completionNode(opts *Options, path string, words []string) (*completionNode, error) {
+	node := &completionNode{
+		Path:     path,
+		Words:    words,
+		Children: make([]*completionNode, 0),
+		Args: []completionArg{
+			{Arg: opts.ArgPrefix + "help"},
+			{Arg: opts.ArgPrefix + "interactive", Values: []string{"true", "false"}},
+		},
+	}
+
+	for _, importer := range opts.importers() {
+		node.Args = append(node.Args, completionArg{Arg: opts.ArgPrefix + importer.Name, Files: true})
+	}
+	for _, exporter := range opts.exporters() {
+		node.Args = append(node.Args, completionArg{Arg: opts.ArgPrefix + opts.ArgExportPrefix + exporter.Name, Files: true})
+	}
+
+	if entry.Command == nil {
+		return node, nil
+	}
+
+	args, err := getArgInfos(opts, GetInstance(reflect.New(reflect.TypeOf(entry.Command)).Elem()))
+	if err != nil {
+		return nil, err
+	}
+
+	if explain := opts.explainArg(args); explain != "" {
+		node.Args = append(node.Args, completionArg{Arg: explain})
+	}
+
+	for _, arg := range args {
+		completion := completionArg{Arg: arg.Arg}
+		if arg.Choices.HasChoices() {
+			completion.Values = arg.Choices.Texts()
+		} else if arg.Prop.IsBool() {
+			completion.Values = []string{"true", "false"}
+		}
+		if arg.Prop.IsPositional() {
+			node.Positionals = append(node.Positionals, completion.Values...)
+		}
+		node.Args = append(node.Args, completion)
+	}
+
+	return node, nil
+}
+
+// Returns the non-empty name and aliases of the entry.
+func (entry Entry) names() []string {
+	names := make([]string, 0, len(entry.Aliases)+1)
+	if entry.Name != "" {
+		names = append(names, entry.Name)
+	}
+	for _, alias := range entry.Aliases {
+		if alias != "" {
+			names = append(names, alias)
+		}
+	}
+	return names
+}
+
+// Returns all nodes in the tree, depth first.
+func (node *completionNode) all() []*completionNode {
+	all := []*completionNode{node}
+	for _, child := range node.Children {
+		all = append(all, child.all()...)
+	}
+	return all
+}
+
+// Returns the words that can complete the current word at this node.
+func (node *completionNode) candidates() []string {
+	candidates := make([]string, 0)
+	for _, child := range node.Children {
+		candidates = append(candidates, child.Words...)
+	}
+	candidates = append(candidates, node.Positionals...)
+	for _, arg := range node.Args {
+		candidates = append(candidates, arg.Arg)
+	}
+	return candidates
+}
+
+type completionGenerator struct {
+	program  string
+	function string
+	root     *completionNode
+}
+
+// Returns the patterns which match the command path of this node when the parent
+// path is followed by one of the words of this node.
+func (node *completionNode) resolvePatterns() []string {
+	parent := node.Path[:strings.LastIndex(node.Path, "/")]
+	patterns := make([]string, len(node.Words))
+	for i, word := range node.Words {
+		patterns[i] = shellQuote(parent + "/" + word)
+	}
+	return patterns
+}
+
+// Writes the arms of a shell case statement which resolves the command path given the next word.
+func (gen completionGenerator) writeResolveCases(out *strings.Builder, indent string) {
+	for _, node := range gen.root.all() {
+		if node == gen.root {
+			continue
+		}
+		fmt.Fprintf(out, "%s%s) cmdpath=%s ;;\n", indent, strings.Join(node.resolvePatterns(), "|"), shellQuote(node.Path))
+	}
+}
+
+func (gen completionGenerator) bash() string {
+	out := &strings.Builder{}
+
+	fmt.Fprintf(out, "# bash completion for %s\n", gen.program)
+	fmt.Fprintf(out, "%s() {\n", gen.function)
+	fmt.Fprintf(out, "  local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(out, "  local prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(out, "  local cmdpath=\"\" i\n")
+	fmt.Fprintf(out, "  for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	fmt.Fprintf(out, "    case \"$cmdpath/${COMP_WORDS[i]}\" in\n")
+	gen.writeResolveCases(out, "      ")
+	fmt.Fprintf(out, "      *) break ;;\n")
+	fmt.Fprintf(out, "    esac\n")
+	fmt.Fprintf(out, "  done\n")
+	fmt.Fprintf(out, "  case \"$cmdpath $prev\" in\n")
+	for _, node := range gen.root.all() {
+		for _, arg := range node.Args {
+			key := shellQuote(node.Path + " " + arg.Arg)
+			if arg.Files {
+				fmt.Fprintf(out, "    %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", key)
+			} else if len(arg.Values) > 0 {
+				fmt.Fprintf(out, "    %s) COMPREPLY=($(compgen -W %s -- \"$cur\")); return ;;\n", key, shellQuote(strings.Join(arg.Values, " ")))
+			}
+		}
+	}
+	fmt.Fprintf(out, "  esac\n")
+	fmt.Fprintf(out, "  case \"$cmdpath\" in\n")
+	for _, node := range gen.root.all() {
+		fmt.Fprintf(out, "    %s) COMPREPLY=($(compgen -W %s -- \"$cur\")) ;;\n", shellQuote(node.Path), shellQuote(strings.Join(node.candidates(), " ")))
+	}
+	fmt.Fprintf(out, "  esac\n")
+	fmt.Fprintf(out, "}\n")
+	fmt.Fprintf(out, "complete -F %s %s\n", gen.function, gen.program)
+
+	return out.String()
+}
+
+func (gen completionGenerator) zsh() string {
+	out := &strings.Builder{}
+
+	fmt.Fprintf(out, "#compdef %s\n", gen.program)
+	fmt.Fprintf(out, "# zsh completion for %s\n", gen.program)
+	fmt.Fprintf(out, "%s() {\n", gen.function)
+	fmt.Fprintf(out, "  local cur=\"${words[CURRENT]}\"\n")
+	fmt.Fprintf(out, "  local prev=\"${words[CURRENT-1]}\"\n")
+	fmt.Fprintf(out, "  local cmdpath=\"\" i\n")
+	fmt.Fprintf(out, "  for ((i = 2; i < CURRENT; i++)); do\n")
+	fmt.Fprintf(out, "    case \"$cmdpath/${words[i]}\" in\n")
+	gen.writeResolveCases(out, "      ")
+	fmt.Fprintf(out, "      *) break ;;\n")
+	fmt.Fprintf(out, "    esac\n")
+	fmt.Fprintf(out, "  done\n")
+	fmt.Fprintf(out, "  case \"$cmdpath $prev\" in\n")
+	for _, node := range gen.root.all() {
+		for _, arg := range node.Args {
+			key := shellQuote(node.Path + " " + arg.Arg)
+			if arg.Files {
+				fmt.Fprintf(out, "    %s) _files; return ;;\n", key)
+			} else if len(arg.Values) > 0 {
+				fmt.Fprintf(out, "    %s) compadd -- %s; return ;;\n", key, shellQuoteAll(arg.Values))
+			}
+		}
+	}
+	fmt.Fprintf(out, "  esac\n")
+	fmt.Fprintf(out, "  case \"$cmdpath\" in\n")
+	for _, node := range gen.root.all() {
+		fmt.Fprintf(out, "    %s) compadd -- %s ;;\n", shellQuote(node.Path), shellQuoteAll(node.candidates()))
+	}
+	fmt.Fprintf(out, "  esac\n")
+	fmt.Fprintf(out, "}\n")
+	fmt.Fprintf(out, "compdef %s %s\n", gen.function, gen.program)
+
+	return out.String()
+}
+
+func (gen completionGenerator) fish() string {
+	out := &strings.Builder{}
+
+	fmt.Fprintf(out, "# fish completion for %s\n", gen.program)
+	fmt.Fprintf(out, "function %s_at\n", gen.function)
+	fmt.Fprintf(out, "  set -l words (commandline -opc)\n")
+	fmt.Fprintf(out, "  set -l cmdpath \"\"\n")
+	fmt.Fprintf(out, "  for word in $words[2..-1]\n")
+	fmt.Fprintf(out, "    switch \"$cmdpath/$word\"\n")
+	for _, node := range gen.root.all() {
+		if node == gen.root {
+			continue
+		}
+		fmt.Fprintf(out, "      case %s\n", strings.Join(node.resolvePatterns(), " "))
+		fmt.Fprintf(out, "        set cmdpath %s\n", shellQuote(node.Path))
+	}
+	fmt.Fprintf(out, "      case '*'\n")
+	fmt.Fprintf(out, "        break\n")
+	fmt.Fprintf(out, "    end\n")
+	fmt.Fprintf(out, "  end\n")
+	fmt.Fprintf(out, "  test \"$cmdpath\" = \"$argv[1]\"\n")
+	fmt.Fprintf(out, "end\n")
+	fmt.Fprintf(out, "function %s_after\n", gen.function)
+	fmt.Fprintf(out, "  set -l words (commandline -opc)\n")
+	fmt.Fprintf(out, "  contains -- \"$words[-1]\" $argv\n")
+	fmt.Fprintf(out, "end\n")
+	fmt.Fprintf(out, "complete -c %s -f\n", gen.program)
+
+	for _, node := range gen.root.all() {
+		atNode := fmt.Sprintf("%s_at %s", gen.function, shellQuote(node.Path))
+		valued := make([]string, 0)
+
+		for _, arg := range node.Args {
+			afterArg := fmt.Sprintf("%s; and %s_after %s", atNode, gen.function, shellQuote(arg.Arg))
+			if arg.Files {
+				fmt.Fprintf(out, "complete -c %s -n %s -F\n", gen.program, shellQuote(afterArg))
+			} else if len(arg.Values) > 0 {
+				fmt.Fprintf(out, "complete -c %s -n %s -a %s\n", gen.program, shellQuote(afterArg), shellQuote(strings.Join(arg.Values, " ")))
+			} else {
+				continue
+			}
+			valued = append(valued, arg.Arg)
+		}
+
+		condition := atNode
+		if len(valued) > 0 {
+			condition = fmt.Sprintf("%s; and not %s_after %s", atNode, gen.function, shellQuoteAll(valued))
+		}
+		fmt.Fprintf(out, "complete -c %s -n %s -a %s\n", gen.program, shellQuote(condition), shellQuote(strings.Join(node.candidates(), " ")))
+	}
+
+	return out.String()
+}
+
+func (gen completionGenerator) bashDynamic() string {
+	out := &strings.Builder{}
+
+	fmt.Fprintf(out, "# bash completion for %s\n", gen.program)
+	fmt.Fprintf(out, "%s() {\n", gen.function)
+	fmt.Fprintf(out, "  local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(out, "  local IFS=$'\\n' candidate\n")
+	fmt.Fprintf(out, "  local candidates=($(%s %s \"${COMP_WORDS[@]:1:COMP_CWORD}\" 2>/dev/null))\n", gen.program, CompleteCommandName)
+	fmt.Fprintf(out, "  COMPREPLY=()\n")
+	fmt.Fprintf(out, "  for candidate in \"${candidates[@]}\"; do\n")
+	fmt.Fprintf(out, "    if [[ \"$candidate\" == %s ]]; then\n", shellQuote(CompleteFilesDirective))
+	fmt.Fprintf(out, "      COMPREPLY+=($(compgen -f -- \"$cur\"))\n")
+	fmt.Fprintf(out, "    else\n")
+	fmt.Fprintf(out, "      COMPREPLY+=(\"$candidate\")\n")
+	fmt.Fprintf(out, "    fi\n")
+	fmt.Fprintf(out, "  done\n")
+	fmt.Fprintf(out, "}\n")
+	fmt.Fprintf(out, "complete -F %s %s\n", gen.function, gen.program)
+
+	return out.String()
+}
+
+func (gen completionGenerator) zshDynamic() string {
+	out := &strings.Builder{}
+
+	fmt.Fprintf(out, "#compdef %s\n", gen.program)
+	fmt.Fprintf(out, "# zsh completion for %s\n", gen.program)
+	fmt.Fprintf(out, "%s() {\n", gen.function)
+	fmt.Fprintf(out, "  local candidate\n")
+	fmt.Fprintf(out, "  local -a candidates\n")
+	fmt.Fprintf(out, "  candidates=(\"${(@f)$(%s %s \"${(@)words[2,CURRENT]}\" 2>/dev/null)}\")\n", gen.program, CompleteCommandName)
+	fmt.Fprintf(out, "  for candidate in $candidates; do\n")
+	fmt.Fprintf(out, "    if [[ \"$candidate\" == %s ]]; then\n", shellQuote(CompleteFilesDirective))
+	fmt.Fprintf(out, "      _files\n")
+	fmt.Fprintf(out, "    else\n")
+	fmt.Fprintf(out, "      compadd -- \"$candidate\"\n")
+	fmt.Fprintf(out, "    fi\n")
+	fmt.Fprintf(out, "  done\n")
+	fmt.Fprintf(out, "}\n")
+	fmt.Fprintf(out, "compdef %s %s\n", gen.function, gen.program)
+
+	return out.String()
+}
+
+func (gen completionGenerator) fishDynamic() string {
+	out := &strings.Builder{}
+
+	fmt.Fprintf(out, "# fish completion for %s\n", gen.program)
+	fmt.Fprintf(out, "function %s_complete\n", gen.function)
+	fmt.Fprintf(out, "  set -l current (commandline -ct)\n")
+	fmt.Fprintf(out, "  set -l words (commandline -opc) \"$current\"\n")
+	fmt.Fprintf(out, "  for candidate in (%s %s $words[2..-1] 2>/dev/null)\n", gen.program, CompleteCommandName)
+	fmt.Fprintf(out, "    if test \"$candidate\" = %s\n", shellQuote(CompleteFilesDirective))
+	fmt.Fprintf(out, "      __fish_complete_path \"$current\"\n")
+	fmt.Fprintf(out, "    else\n")
+	fmt.Fprintf(out, "      echo $candidate\n")
+	fmt.Fprintf(out, "    end\n")
+	fmt.Fprintf(out, "  end\n")
+	fmt.Fprintf(out, "end\n")
+	fmt.Fprintf(out, "complete -c %s -f -a '(%s_complete)'\n", gen.program, gen.function)
+
+	return out.String()
+}
+
+// An argument a command accepts.
+type argInfo struct {
+	// The full argument, ex: "--movies-1-title"
+	Arg string
+	// The property populated by the argument.
+	Prop *Property
+	// The choices for the argument value.
+	Choices PromptChoices
+}
+
+// Returns all arguments that can populate the given instance, including the templated arguments
+// of nested structs, slices, arrays, and maps. Slices and maps are given the first index.
+func getArgInfos(opts *Options, instance Instance) ([]argInfo, error) {
+	return appendArgInfos(opts, make([]argInfo, 0), instance, opts.ArgPrefix, map[reflect.Type]struct{}{})
+}
+
+func appendArgInfos(opts *Options, infos []argInfo, instance Instance, argPrefix string, avoidTypes map[reflect.Type]struct{}) ([]argInfo, error) {
+	for _, prop := range instance.PropertyList {
+		if !prop.CanFromArgs() {
+			continue
+		}
+
+		if prop.getArgValue(opts) != nil || prop.IsSimple() {
+			infos = append(infos, argInfo{
+				Arg:     strings.ToLower(argPrefix + prop.Arg),
+				Prop:    prop,
+				Choices: prop.GetPromptChoices(opts),
+			})
+			continue
+		}
+
+		propType := prop.ConcreteType()
+		if _, exists := avoidTypes[propType]; exists {
+			continue
+		}
+		avoidTypes[propType] = struct{}{}
+
+		var err error
+
+		switch {
+		case prop.IsStruct():
+			infos, err = appendArgInfosTemplate(opts, infos, *prop, propType, argPrefix, opts.ArgStructTemplate, []int{0}, avoidTypes)
+		case prop.IsSlice():
+			infos, err = appendArgInfosTemplate(opts, infos, *prop, propType.Elem(), argPrefix, opts.ArgSliceTemplate, []int{opts.ArgStartIndex}, avoidTypes)
+		case prop.IsArray():
+			indices := make([]int, propType.Len())
+			for i := range indices {
+				indices[i] = i + opts.ArgStartIndex
+			}
+			infos, err = appendArgInfosTemplate(opts, infos, *prop, propType.Elem(), argPrefix, opts.ArgArrayTemplate, indices, avoidTypes)
+		case prop.IsMap():
+			infos, err = appendArgInfosTemplate(opts, infos, *prop, propType.Key(), argPrefix, opts.ArgMapKeyTemplate, []int{opts.ArgStartIndex}, avoidTypes)
+			if err == nil {
+				infos, err = appendArgInfosTemplate(opts, infos, *prop, propType.Elem(), argPrefix, opts.ArgMapValueTemplate, []int{opts.ArgStartIndex}, avoidTypes)
+			}
+		}
+
+		delete(avoidTypes, propType)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return infos, nil
+}
+
+func appendArgInfosTemplate(opts *Options, infos []argInfo, prop Property, typ reflect.Type, argPrefix string, tpl *template.Template, indices []int, avoidTypes map[reflect.Type]struct{}) ([]argInfo, error) {
+	argTemplate := prop.getArgTemplate(argPrefix, concreteType(typ).Kind(), tpl)
+
+	for _, index := range indices {
+		argTemplate.Index = index
+		prefix, err := argTemplate.get()
+		if err != nil {
+			return nil, err
+		}
+
+		instance := GetSubInstance(reflect.New(typ).Elem(), prop)
+		infos, err = appendArgInfos(opts, infos, instance, prefix, avoidTypes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return infos, nil
+}
+
+// Returns the keys of the map in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Quotes the text so its interpreted literally by a shell.
+func shellQuote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
+
+// Quotes each of the texts and joins them with a space.
+func shellQuoteAll(texts []string) string {
+	quoted := make([]string, len(texts))
+	for i, text := range texts {
+		quoted[i] = shellQuote(text)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,299 @@
+package cmdgo
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+type CompletionMovie struct {
+	Title  string
+	Rating float32
+}
+
+type CompletionCommandTest struct {
+	Message string `arg:"msg"`
+	Color   string `options:"red,green,blue"`
+	Verbose bool
+	Movies  []CompletionMovie
+	Tags    map[string]int
+	Pair    [2]int
+	Skip    string `arg:"-"`
+}
+
+func TestGetArgInfos(t *testing.T) {
+	opts := NewOptions()
+
+	infos, err := getArgInfos(opts, GetInstance(&CompletionCommandTest{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := make([]string, len(infos))
+	for i, info := range infos {
+		actual[i] = info.Arg
+	}
+
+	expected := []string{
+		"--msg",
+		"--color",
+		"--verbose",
+		"--movies-1-title",
+		"--movies-1-rating",
+		"--tags-key",
+		"--tags-value",
+		"--pair-1",
+		"--pair-2",
+	}
+
+	if strings.Join(actual, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	registry := CreateRegistry([]Entry{
+		{Name: "test", Aliases: []string{"t"}, Command: CompletionCommandTest{}},
+		{Name: "sub", Sub: CreateRegistry([]Entry{
+			{Name: "simple", Command: SimpleCommand{}},
+		})},
+	})
+	registry.AddCompletion()
+
+	tests := []struct {
+		shell    string
+		expected []string
+	}{
+		{
+			shell: "bash",
+			expected: []string{
+				"_my_program() {",
+				"'/test'|'/t') cmdpath='/test' ;;",
+				"'/sub/simple') cmdpath='/sub/simple' ;;",
+				"'/test --color') COMPREPLY=($(compgen -W 'blue green red' -- \"$cur\")); return ;;",
+				"'/test --json') COMPREPLY=($(compgen -f -- \"$cur\")); return ;;",
+				"'') COMPREPLY=($(compgen -W 'test t sub completion --help' -- \"$cur\")) ;;",
+				"'/sub/simple') COMPREPLY=($(compgen -W '--help --interactive --json --yaml --xml --toml --ini --env-file --save-json --save-yaml --save-xml --save-toml --explain --message' -- \"$cur\")) ;;",
+				"complete -F _my_program my-program",
+			},
+		},
+		{
+			shell: "zsh",
+			expected: []string{
+				"#compdef my-program",
+				"'/test --color') compadd -- 'blue' 'green' 'red'; return ;;",
+				"'/test --yaml') _files; return ;;",
+				"compdef _my_program my-program",
+			},
+		},
+		{
+			shell: "fish",
+			expected: []string{
+				"function _my_program_at",
+				"case '/test' '/t'",
+				"complete -c my-program -n '_my_program_at '\\''/test'\\''; and _my_program_after '\\''--color'\\''' -a 'blue green red'",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		opts := NewOptions()
+		script, err := registry.Completion(opts, test.shell, "my-program")
+		if err != nil {
+			t.Errorf("Test [%s] failed with error %v", test.shell, err)
+			continue
+		}
+		for _, expected := range test.expected {
+			if !strings.Contains(script, expected) {
+				t.Errorf("Test [%s] expected script to contain %q, got:\n%s", test.shell, expected, script)
+			}
+		}
+	}
+
+	_, err := registry.Completion(NewOptions(), "powershell", "my-program")
+	if err != ErrUnsupportedShell {
+		t.Errorf("Expected %v but got %v", ErrUnsupportedShell, err)
+	}
+}
+
+func TestCompletionDynamic(t *testing.T) {
+	registry := CreateRegistry([]Entry{
+		{Name: "simple", Command: SimpleCommand{}},
+	})
+
+	// The current word is passed even when it's empty so the program completes the next word.
+	tests := []struct {
+		shell    string
+		expected []string
+	}{
+		{
+			shell: "bash",
+			expected: []string{
+				"local candidates=($(my-program __complete \"${COMP_WORDS[@]:1:COMP_CWORD}\" 2>/dev/null))",
+				"complete -F _my_program my-program",
+			},
+		},
+		{
+			shell: "zsh",
+			expected: []string{
+				"candidates=(\"${(@f)$(my-program __complete \"${(@)words[2,CURRENT]}\" 2>/dev/null)}\")",
+				"compdef _my_program my-program",
+			},
+		},
+		{
+			shell: "fish",
+			expected: []string{
+				"set -l current (commandline -ct)",
+				"set -l words (commandline -opc) \"$current\"",
+				"for candidate in (my-program __complete $words[2..-1] 2>/dev/null)",
+				"__fish_complete_path \"$current\"",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		script, err := registry.CompletionDynamic(test.shell, "my-program")
+		if err != nil {
+			t.Errorf("Test [%s] failed with error %v", test.shell, err)
+			continue
+		}
+		for _, expected := range test.expected {
+			if !strings.Contains(script, expected) {
+				t.Errorf("Test [%s] expected script to contain %q, got:\n%s", test.shell, expected, script)
+			}
+		}
+	}
+}
+
+func TestCompletionCommand(t *testing.T) {
+	registry := CreateRegistry([]Entry{
+		{Name: "simple", Command: SimpleCommand{}},
+	})
+	registry.AddCompletion()
+
+	opts := NewOptions().WithArgs([]string{"completion", "--shell", "bash", "--program", "prog"})
+
+	cmd, err := registry.ExecuteReturn(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completion, ok := cmd.(*CompletionCommand); !ok || completion.Shell != "bash" {
+		t.Errorf("Expected completion command for bash but got %+v", cmd)
+	}
+}
+
+type CompletionRegion string
+
+func (region *CompletionRegion) GetCompletions(opts *Options, prop *Property, partial string) []string {
+	return opts.Values["regions"].([]string)
+}
+
+type CompletionRuntimeCommand struct {
+	Region CompletionRegion
+	Size   string `options:"small,large"`
+	Debug  bool
+}
+
+func TestComplete(t *testing.T) {
+	registry := CreateRegistry([]Entry{
+		{Name: "deploy", Command: CompletionRuntimeCommand{}},
+		{Name: "sub", Sub: CreateRegistry([]Entry{
+			{Name: "simple", Command: SimpleCommand{}},
+			{Name: "second", Command: SimpleCommand{}},
+		})},
+	})
+	registry.AddCompletion()
+
+	tests := []struct {
+		name     string
+		words    []string
+		expected []string
+		files    bool
+	}{
+		{
+			name:     "root",
+			words:    []string{""},
+			expected: []string{"--help", "deploy", "sub", "completion"},
+		},
+		{
+			name:     "root partial",
+			words:    []string{"d"},
+			expected: []string{"deploy"},
+		},
+		{
+			name:     "sub",
+			words:    []string{"sub", "s"},
+			expected: []string{"simple", "second"},
+		},
+		{
+			name:     "unknown",
+			words:    []string{"nope", ""},
+			expected: []string{},
+		},
+		{
+			name:     "args",
+			words:    []string{"deploy", "--s"},
+			expected: []string{"--save-json", "--save-yaml", "--save-xml", "--save-toml", "--size"},
+		},
+		{
+			name:     "args after flag",
+			words:    []string{"deploy", "--debug", "--r"},
+			expected: []string{"--region"},
+		},
+		{
+			name:     "choices",
+			words:    []string{"deploy", "--size", ""},
+			expected: []string{"large", "small"},
+		},
+		{
+			name:     "runtime",
+			words:    []string{"deploy", "--size", "small", "--region", "us"},
+			expected: []string{"us-east", "us-west"},
+		},
+		{
+			name:  "files",
+			words: []string{"deploy", "--json", ""},
+			files: true,
+		},
+	}
+
+	for _, test := range tests {
+		opts := NewOptions()
+		opts.Values["regions"] = []string{"us-east", "us-west", "eu-central"}
+
+		completions, err := registry.Complete(opts, test.words)
+		if err != nil {
+			t.Errorf("Test [%s] failed with error %v", test.name, err)
+		} else if completions.Files != test.files {
+			t.Errorf("Test [%s] expected files %v but got %v", test.name, test.files, completions.Files)
+		} else if !test.files && strings.Join(completions.Candidates, " ") != strings.Join(test.expected, " ") {
+			t.Errorf("Test [%s] expected %v but got %v", test.name, test.expected, completions.Candidates)
+		}
+	}
+}
+
+func TestCompleteCommand(t *testing.T) {
+	registry := CreateRegistry([]Entry{
+		{Name: "deploy", Command: CompletionRuntimeCommand{}},
+	})
+	registry.AddCompletion()
+
+	out, err := os.CreateTemp("", "complete")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(out.Name())
+
+	opts := NewOptions().WithArgs([]string{CompleteCommandName, "deploy", "--help", "--size", ""})
+	opts.WithFiles(nil, out)
+
+	err = registry.Execute(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	printed, _ := os.ReadFile(out.Name())
+	if string(printed) != "large\nsmall\n" {
+		t.Errorf("Expected candidates to be printed but got %q", printed)
+	}
+}
@@ -0,0 +1,140 @@
+package cmdgo
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// A file extension searched for during config file discovery and the name of the importer for it.
+type ConfigExtension struct {
+	Extension string
+	Importer  string
+}
+
+// The file extensions searched for during config file discovery, in order of preference.
+var ConfigExtensions = []ConfigExtension{
+	{Extension: "yaml", Importer: "yaml"},
+	{Extension: "yml", Importer: "yaml"},
+	{Extension: "json", Importer: "json"},
+	{Extension: "xml", Importer: "xml"},
+	{Extension: "toml", Importer: "toml"},
+}
+
+// Returns the directories searched for config files in order of precedence: the working directory,
+// $XDG_CONFIG_HOME/<program> (or ~/.config/<program> when not set), and the home directory.
+// The program directory is only included if the program is not empty.
+func DefaultConfigDirs(program string) []string {
+	dirs := make([]string, 0, 3)
+
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, wd)
+	}
+
+	home, homeErr := os.UserHomeDir()
+
+	if program != "" {
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			dirs = append(dirs, filepath.Join(xdg, program))
+		} else if homeErr == nil {
+			dirs = append(dirs, filepath.Join(home, ".config", program))
+		}
+	}
+
+	if homeErr == nil {
+		dirs = append(dirs, home)
+	}
+
+	return dirs
+}
+
+// Returns the config files which exist for the entry in order of precedence. Each config directory
+// is searched for each config name with each extension in ConfigExtensions that has an importer.
+func (opts *Options) FindConfigFiles(entry *Entry) []string {
+	names := entry.ConfigNames
+	if len(names) == 0 && opts.DiscoverConfig && entry.Name != "" {
+		names = []string{entry.Name}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	dirs := opts.ConfigDirs
+	if dirs == nil {
+		dirs = DefaultConfigDirs(opts.ProgramName)
+	}
+
+	found := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, dir := range dirs {
+		for _, name := range names {
+			for _, ext := range ConfigExtensions {
+				if opts.Importer(ext.Importer) == nil {
+					continue
+				}
+				path := filepath.Join(dir, name+"."+ext.Extension)
+				if abs, err := filepath.Abs(path); err == nil {
+					path = abs
+				}
+				if seen[path] {
+					continue
+				}
+				if info, err := os.Stat(path); err == nil && !info.IsDir() {
+					found = append(found, path)
+					seen[path] = true
+				}
+			}
+		}
+	}
+
+	return found
+}
+
+// Imports the config files found for the entry into the command and records them in ConfigFiles.
+func (opts *Options) importConfigFiles(entry *Entry, command any) error {
+	opts.ConfigFiles = nil
+
+	found := opts.FindConfigFiles(entry)
+	if len(found) == 0 {
+		return nil
+	}
+
+	paths := found[:1]
+	if opts.ConfigMerge {
+		paths = make([]string, len(found))
+		for i, path := range found {
+			paths[len(found)-1-i] = path
+		}
+	}
+
+	for _, path := range paths {
+		data, err := opts.readImport(path)
+		if err != nil {
+			return err
+		}
+		importer := opts.Importer(configImporter(path))
+		err = opts.recordImport(OriginConfig, path, command, func() error {
+			return importer.Import(data, command)
+		})
+		if err != nil {
+			return err
+		}
+		opts.ConfigFiles = append(opts.ConfigFiles, path)
+	}
+
+	return nil
+}
+
+// Returns the name of the importer for the config file path.
+func configImporter(path string) string {
+	ext := filepath.Ext(path)
+	if ext != "" {
+		ext = ext[1:]
+	}
+	for _, configExt := range ConfigExtensions {
+		if configExt.Extension == ext {
+			return configExt.Importer
+		}
+	}
+	return ""
+}
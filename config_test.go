@@ -0,0 +1,101 @@
+package cmdgo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigDiscovery(t *testing.T) {
+	local := t.TempDir()
+	global := t.TempDir()
+	files := map[string]string{
+		filepath.Join(local, "simple.yaml"):  "message: local",
+		filepath.Join(global, "simple.json"): `{"Message": "global"}`,
+		filepath.Join(global, "named.toml"):  `Message = "named"`,
+	}
+	for path, content := range files {
+		err := os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry := CreateRegistry([]Entry{
+		{Name: "simple", Command: SimpleCommand{}},
+		{Name: "configured", ConfigNames: []string{"named"}, Command: SimpleCommand{}},
+	})
+
+	tests := []struct {
+		name     string
+		args     []string
+		discover bool
+		merge    bool
+		expected string
+		files    []string
+	}{
+		{
+			name:     "not discovered",
+			args:     []string{"simple", "--interactive", "false"},
+			expected: "",
+		},
+		{
+			name:     "first found",
+			args:     []string{"simple", "--interactive", "false"},
+			discover: true,
+			expected: "local",
+			files:    []string{filepath.Join(local, "simple.yaml")},
+		},
+		{
+			name:     "merged",
+			args:     []string{"simple", "--interactive", "false"},
+			discover: true,
+			merge:    true,
+			expected: "local",
+			files:    []string{filepath.Join(global, "simple.json"), filepath.Join(local, "simple.yaml")},
+		},
+		{
+			name:     "config names",
+			args:     []string{"configured", "--interactive", "false"},
+			expected: "named",
+			files:    []string{filepath.Join(global, "named.toml")},
+		},
+		{
+			name:     "args over config",
+			args:     []string{"simple", "--message", "arg"},
+			discover: true,
+			expected: "arg",
+			files:    []string{filepath.Join(local, "simple.yaml")},
+		},
+	}
+
+	for _, test := range tests {
+		opts := NewOptions().WithArgs(test.args)
+		opts.ConfigDirs = []string{local, global}
+		opts.DiscoverConfig = test.discover
+		opts.ConfigMerge = test.merge
+
+		cmd, err := registry.Capture(opts)
+		if err != nil {
+			t.Errorf("Test [%s] failed with error %v", test.name, err)
+		} else if simple := cmd.(*SimpleCommand); simple.Message != test.expected {
+			t.Errorf("Test [%s] expected message %q but got %q", test.name, test.expected, simple.Message)
+		} else if !equalsJson(opts.ConfigFiles, test.files) {
+			t.Errorf("Test [%s] expected config files %v but got %v", test.name, test.files, opts.ConfigFiles)
+		}
+	}
+}
+
+func TestDefaultConfigDirs(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	dirs := DefaultConfigDirs("myprogram")
+	wd, _ := os.Getwd()
+	home, _ := os.UserHomeDir()
+	expected := []string{wd, filepath.Join(xdg, "myprogram"), home}
+
+	if !equalsJson(dirs, expected) {
+		t.Errorf("Expected %v but got %v", expected, dirs)
+	}
+}
@@ -0,0 +1,384 @@
+package cmdgo
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+// A key pressed on a terminal.
+type terminalKey int
+
+const (
+	terminalKeyNone terminalKey = iota
+	terminalKeyEnter
+	terminalKeyQuit
+	terminalKeyEscape
+	terminalKeyUp
+	terminalKeyDown
+	terminalKeyLeft
+	terminalKeyRight
+	terminalKeyHome
+	terminalKeyEnd
+	terminalKeyBackspace
+	terminalKeyDelete
+	terminalKeyEndOfInput
+	terminalKeyTab
+	terminalKeyKillEnd
+	terminalKeyKillStart
+	terminalKeyKillWord
+	terminalKeyRune
+)
+
+// Reads the next key pressed from the input, returning the rune typed for terminalKeyRune.
+func readTerminalKey(in *bufio.Reader) (terminalKey, rune, error) {
+	key, _, err := in.ReadRune()
+	if err != nil {
+		return terminalKeyNone, 0, err
+	}
+
+	switch key {
+	case '\r', '\n':
+		return terminalKeyEnter, key, nil
+	case 3: // ctrl+c
+		return terminalKeyQuit, key, nil
+	case 27: // escape or the start of an escape sequence
+		if in.Buffered() == 0 {
+			return terminalKeyEscape, key, nil
+		}
+		sequence, _ := in.ReadByte()
+		if sequence != '[' && sequence != 'O' {
+			return terminalKeyNone, key, nil
+		}
+		// A sequence is parameter bytes (ex: 1;5 for ctrl) up to its final byte, which is read even if it's not known.
+		parameters := []byte{}
+		code, err := in.ReadByte()
+		for sequence == '[' && err == nil && (code < 0x40 || code > 0x7E) {
+			parameters = append(parameters, code)
+			code, err = in.ReadByte()
+		}
+		if err != nil {
+			return terminalKeyNone, key, nil
+		}
+		if code == '~' {
+			// sequences like ESC [ 3 ~ are numbered
+			number, _, _ := strings.Cut(string(parameters), ";")
+			switch number {
+			case "1", "7":
+				return terminalKeyHome, key, nil
+			case "3":
+				return terminalKeyDelete, key, nil
+			case "4", "8":
+				return terminalKeyEnd, key, nil
+			}
+			return terminalKeyNone, key, nil
+		}
+		switch code {
+		case 'A':
+			return terminalKeyUp, key, nil
+		case 'B':
+			return terminalKeyDown, key, nil
+		case 'C':
+			return terminalKeyRight, key, nil
+		case 'D':
+			return terminalKeyLeft, key, nil
+		case 'H':
+			return terminalKeyHome, key, nil
+		case 'F':
+			return terminalKeyEnd, key, nil
+		}
+	case 127, 8: // backspace
+		return terminalKeyBackspace, key, nil
+	case 1: // ctrl+a
+		return terminalKeyHome, key, nil
+	case 2: // ctrl+b
+		return terminalKeyLeft, key, nil
+	case 4: // ctrl+d
+		return terminalKeyEndOfInput, key, nil
+	case 5: // ctrl+e
+		return terminalKeyEnd, key, nil
+	case 6: // ctrl+f
+		return terminalKeyRight, key, nil
+	case 9: // tab
+		return terminalKeyTab, key, nil
+	case 11: // ctrl+k
+		return terminalKeyKillEnd, key, nil
+	case 14: // ctrl+n
+		return terminalKeyDown, key, nil
+	case 16: // ctrl+p
+		return terminalKeyUp, key, nil
+	case 21: // ctrl+u
+		return terminalKeyKillStart, key, nil
+	case 23: // ctrl+w
+		return terminalKeyKillWord, key, nil
+	default:
+		if unicode.IsPrint(key) {
+			return terminalKeyRune, key, nil
+		}
+	}
+	return terminalKeyNone, key, nil
+}
+
+// Returns whether the options input and output are terminals, in which case lines are read with a line editor.
+func (opts *Options) isTerminal() bool {
+	return opts.in != nil && opts.out != nil && term.IsTerminal(int(opts.in.Fd())) && term.IsTerminal(int(opts.out.Fd()))
+}
+
+// Reads a line from the terminal with a line editor which recalls the history of the options and completes the
+// completions of the options. io.EOF is returned if ctrl+d is pressed on an empty line.
+func (opts *Options) editLine(prompt string, options PromptOnceOptions) (string, error) {
+	state, err := term.MakeRaw(int(opts.in.Fd()))
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(int(opts.in.Fd()), state)
+
+	history := []string{}
+	if !options.Multi {
+		history = opts.getHistory(options.History)
+	}
+
+	return runLineEditor(opts.inReader, opts.out, prompt, history, options.Completions)
+}
+
+// Returns the history of answers for the key, loading the history file the first time history is needed.
+func (opts *Options) getHistory(key string) []string {
+	if key == "" {
+		return nil
+	}
+	if opts.history == nil {
+		opts.history = make(map[string][]string)
+		if opts.PromptHistoryFile != "" {
+			// A missing or invalid history file is the same as no history.
+			if data, err := os.ReadFile(opts.PromptHistoryFile); err == nil {
+				json.Unmarshal(data, &opts.history)
+			}
+		}
+	}
+	return opts.history[key]
+}
+
+// Adds the answer to the history for the key and saves the history file if there is one.
+// The answer is not added if it's empty or the same as the last answer.
+func (opts *Options) addHistory(key string, answer string) {
+	history := opts.getHistory(key)
+	if key == "" || answer == "" || (len(history) > 0 && history[len(history)-1] == answer) {
+		return
+	}
+	history = append(history, answer)
+	if opts.PromptHistorySize > 0 && len(history) > opts.PromptHistorySize {
+		history = history[len(history)-opts.PromptHistorySize:]
+	}
+	opts.history[key] = history
+
+	if opts.PromptHistoryFile != "" {
+		// Failing to save history should not stop prompting.
+		if data, err := json.Marshal(opts.history); err == nil {
+			os.WriteFile(opts.PromptHistoryFile, data, 0600)
+		}
+	}
+}
+
+// Returns the key history is recalled and saved under for the value at the path, which is the path
+// without slice indices or map keys so all elements of a slice or map share history.
+func historyKey(path string) string {
+	if path == noValuePath {
+		return ""
+	}
+	key := strings.Builder{}
+	depth := 0
+	for _, c := range path {
+		switch {
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0:
+			key.WriteRune(c)
+		}
+	}
+	return key.String()
+}
+
+// The state of a line being edited.
+type lineEditor struct {
+	prompt      string
+	line        []rune
+	cursor      int
+	history     []string
+	index       int
+	draft       string
+	completions []string
+}
+
+// Sets the line being edited and moves the cursor to the end of it.
+func (editor *lineEditor) set(line string) {
+	editor.line = []rune(line)
+	editor.cursor = len(editor.line)
+}
+
+// Inserts the text at the cursor.
+func (editor *lineEditor) insert(text []rune) {
+	line := make([]rune, 0, len(editor.line)+len(text))
+	line = append(line, editor.line[:editor.cursor]...)
+	line = append(line, text...)
+	line = append(line, editor.line[editor.cursor:]...)
+	editor.line = line
+	editor.cursor += len(text)
+}
+
+// Removes the text between the start and end.
+func (editor *lineEditor) remove(start int, end int) {
+	if start < 0 || end > len(editor.line) || start >= end {
+		return
+	}
+	editor.line = append(editor.line[:start], editor.line[end:]...)
+	editor.cursor = start
+}
+
+// Moves through the history by the given amount, keeping the line being entered as the newest entry.
+func (editor *lineEditor) recall(amount int) {
+	index := editor.index + amount
+	if index < 0 || index > len(editor.history) {
+		return
+	}
+	if editor.index == len(editor.history) {
+		editor.draft = string(editor.line)
+	}
+	editor.index = index
+	if index == len(editor.history) {
+		editor.set(editor.draft)
+	} else {
+		editor.set(editor.history[index])
+	}
+}
+
+// Completes the line with the completions it's the start of (ignoring case). If there is one it replaces the line,
+// otherwise the line is extended to the common start of the completions. If the line can't be extended
+// the completions are returned so they can be listed.
+func (editor *lineEditor) complete() []string {
+	line := strings.ToLower(string(editor.line))
+	matches := []string{}
+	for _, completion := range editor.completions {
+		if strings.HasPrefix(strings.ToLower(completion), line) {
+			matches = append(matches, completion)
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	common := []rune(matches[0])
+	for _, match := range matches[1:] {
+		runes := []rune(match)
+		n := 0
+		for n < len(common) && n < len(runes) && unicode.ToLower(common[n]) == unicode.ToLower(runes[n]) {
+			n++
+		}
+		common = common[:n]
+	}
+	if len(matches) == 1 || len(common) > len(editor.line) {
+		editor.set(string(common))
+		return nil
+	}
+	return matches
+}
+
+// Draws the prompt and line over the previously drawn line, leaving the cursor where it is in the line.
+func (editor *lineEditor) render(out io.Writer) {
+	text := strings.Builder{}
+	text.WriteString("\r\x1b[K")
+	text.WriteString(editor.prompt)
+	text.WriteString(string(editor.line))
+	if back := len(editor.line) - editor.cursor; back > 0 {
+		fmt.Fprintf(&text, "\x1b[%dD", back)
+	}
+	io.WriteString(out, text.String())
+}
+
+// Runs a line editor which reads keys from the input and draws to the output until enter is pressed.
+// The arrow keys, ctrl+a/e/b/f, home, and end move the cursor, up and down (or ctrl+p/n) recall the history,
+// backspace, delete, ctrl+k/u/w remove text, and tab completes the line with the completions.
+// ctrl+c returns ErrQuit and ctrl+d on an empty line returns io.EOF.
+func runLineEditor(in *bufio.Reader, out io.Writer, prompt string, history []string, completions []string) (string, error) {
+	editor := &lineEditor{
+		prompt:      prompt,
+		history:     history,
+		index:       len(history),
+		completions: completions,
+	}
+	editor.render(out)
+
+	for {
+		key, typed, err := readTerminalKey(in)
+		if err != nil {
+			if err == io.EOF && len(editor.line) > 0 {
+				io.WriteString(out, "\r\n")
+				return string(editor.line), nil
+			}
+			return "", err
+		}
+
+		switch key {
+		case terminalKeyEnter:
+			io.WriteString(out, "\r\n")
+			return string(editor.line), nil
+		case terminalKeyQuit:
+			io.WriteString(out, "\r\n")
+			return "", ErrQuit
+		case terminalKeyEndOfInput:
+			if len(editor.line) == 0 {
+				io.WriteString(out, "\r\n")
+				return "", io.EOF
+			}
+			editor.remove(editor.cursor, editor.cursor+1)
+		case terminalKeyUp:
+			editor.recall(-1)
+		case terminalKeyDown:
+			editor.recall(1)
+		case terminalKeyLeft:
+			if editor.cursor > 0 {
+				editor.cursor--
+			}
+		case terminalKeyRight:
+			if editor.cursor < len(editor.line) {
+				editor.cursor++
+			}
+		case terminalKeyHome:
+			editor.cursor = 0
+		case terminalKeyEnd:
+			editor.cursor = len(editor.line)
+		case terminalKeyBackspace:
+			editor.remove(editor.cursor-1, editor.cursor)
+		case terminalKeyDelete:
+			editor.remove(editor.cursor, editor.cursor+1)
+		case terminalKeyKillEnd:
+			editor.remove(editor.cursor, len(editor.line))
+		case terminalKeyKillStart:
+			editor.remove(0, editor.cursor)
+		case terminalKeyKillWord:
+			start := editor.cursor
+			for start > 0 && editor.line[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && editor.line[start-1] != ' ' {
+				start--
+			}
+			editor.remove(start, editor.cursor)
+		case terminalKeyTab:
+			if matches := editor.complete(); matches != nil {
+				io.WriteString(out, "\r\n"+strings.Join(matches, "  ")+"\r\n")
+			}
+		case terminalKeyRune:
+			editor.insert([]rune{typed})
+		}
+
+		editor.render(out)
+	}
+}
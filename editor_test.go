@@ -0,0 +1,91 @@
+package cmdgo
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunLineEditor(t *testing.T) {
+	history := []string{"first", "second"}
+	completions := []string{"Apple", "Apricot", "Banana"}
+
+	tests := []struct {
+		name          string
+		keys          string
+		expected      string
+		expectedError error
+	}{
+		{name: "enter", keys: "hello\r", expected: "hello"},
+		{name: "end of input", keys: "hello", expected: "hello"},
+		{name: "left", keys: "hllo\x1b[D\x1b[D\x1b[De\r", expected: "hello"},
+		{name: "home end", keys: "ell\x01h\x05o\r", expected: "hello"},
+		{name: "home end sequences", keys: "ell\x1b[Hh\x1b[Fo\r", expected: "hello"},
+		{name: "backspace", keys: "helo\x7f\x7fllo\r", expected: "hello"},
+		{name: "delete", keys: "hxello\x01\x1b[C\x1b[3~\r", expected: "hello"},
+		{name: "modified arrows", keys: "hllo\x1b[1;5D\x1b[1;2D\x1b[1;3De\r", expected: "hello"},
+		{name: "unknown sequences", keys: "he\x1b[1;2P\x1b[5;5~\x1b[200~llo\x1bOP\r", expected: "hello"},
+		{name: "kill end", keys: "hello world\x01\x1b[C\x1b[C\x1b[C\x1b[C\x1b[C\x0b\r", expected: "hello"},
+		{name: "kill start", keys: "world hello\x1b[D\x1b[D\x1b[D\x1b[D\x1b[D\x15\x05\r", expected: "hello"},
+		{name: "kill word", keys: "hello world  \x17\r", expected: "hello "},
+		{name: "history up", keys: "\x1b[A\r", expected: "second"},
+		{name: "history up twice", keys: "\x1b[A\x10\x1b[A\r", expected: "first"},
+		{name: "history down to draft", keys: "dra\x1b[A\x1b[A\x1b[B\x0e\x1b[Bft\r", expected: "draft"},
+		{name: "complete one", keys: "b\t\r", expected: "Banana"},
+		{name: "complete common", keys: "a\t\r", expected: "Ap"},
+		{name: "complete list", keys: "ap\t\tr\t\r", expected: "Apricot"},
+		{name: "complete none", keys: "x\t\r", expected: "x"},
+		{name: "ctrl+d empty", keys: "\x04", expectedError: io.EOF},
+		{name: "ctrl+d delete", keys: "hxello\x01\x1b[C\x04\r", expected: "hello"},
+		{name: "quit", keys: "hello\x03", expectedError: ErrQuit},
+	}
+
+	for _, test := range tests {
+		out := strings.Builder{}
+		in := bufio.NewReader(strings.NewReader(test.keys))
+		actual, err := runLineEditor(in, &out, "Name: ", history, completions)
+
+		if err != test.expectedError {
+			t.Errorf("Test [%s] expected error %v but got %v", test.name, test.expectedError, err)
+		} else if actual != test.expected {
+			t.Errorf("Test [%s] expected %q but got %q", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestPromptHistory(t *testing.T) {
+	keys := map[string]string{
+		"Name":                   "Name",
+		"Movies[0].Title":        "Movies.Title",
+		"Labels[a[b]].Value[12]": "Labels.Value",
+		"-":                      "",
+	}
+	for path, expected := range keys {
+		if actual := historyKey(path); actual != expected {
+			t.Errorf("Expected history key %q for %q but got %q", expected, path, actual)
+		}
+	}
+
+	path := t.TempDir() + "/history.json"
+
+	opts := NewOptions()
+	opts.PromptHistoryFile = path
+	opts.PromptHistorySize = 2
+	opts.addHistory("Name", "a")
+	opts.addHistory("Name", "b")
+	opts.addHistory("Name", "b")
+	opts.addHistory("Name", "")
+	opts.addHistory("Name", "c")
+	opts.addHistory("Title", "x")
+	opts.addHistory("", "y")
+
+	loaded := NewOptions()
+	loaded.PromptHistoryFile = path
+	if actual := loaded.getHistory("Name"); !equalsJson(actual, []string{"b", "c"}) {
+		t.Errorf("Expected Name history [b c] but got %v", actual)
+	}
+	if actual := loaded.getHistory("Title"); !equalsJson(actual, []string{"x"}) {
+		t.Errorf("Expected Title history [x] but got %v", actual)
+	}
+}
@@ -0,0 +1,79 @@
+package cmdgo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An argument which was given but not used to populate a command.
+type UnknownArg struct {
+	// The argument as given.
+	Arg string
+	// The known arguments which are similar to the given argument.
+	Suggestions []string
+}
+
+// Formats the unknown argument and any suggestions.
+func (arg UnknownArg) String() string {
+	if len(arg.Suggestions) == 0 {
+		return arg.Arg
+	}
+	return fmt.Sprintf("%s (did you mean %s?)", arg.Arg, strings.Join(arg.Suggestions, " or "))
+}
+
+// An error returned when Options.StrictArgs is true and arguments were given that were not used to populate the command.
+type UnknownArgsError struct {
+	Args []UnknownArg
+}
+
+func (e UnknownArgsError) Error() string {
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = arg.String()
+	}
+	if len(args) == 1 {
+		return "unknown argument: " + args[0]
+	}
+	return "unknown arguments: " + strings.Join(args, ", ")
+}
+
+// An error returned when a command name does not match any commands in a registry.
+type CommandNotFoundError struct {
+	// The names that lead to the registry which was searched.
+	Path []string
+	// The command name given, or empty if the names ended before a command was found.
+	Name string
+	// The names and aliases in the registry which are similar to the given name, or all of them if no name was given.
+	Suggestions []string
+}
+
+func (e CommandNotFoundError) Error() string {
+	text := ""
+	if e.Name == "" {
+		text = "missing command after: " + strings.Join(e.Path, " ")
+	} else {
+		text = "command not found: " + strings.Join(append(e.Path[:len(e.Path):len(e.Path)], e.Name), " ")
+	}
+	if len(e.Suggestions) > 0 {
+		text += fmt.Sprintf(" (did you mean %s?)", strings.Join(e.Suggestions, " or "))
+	}
+	return text
+}
+
+// An error returned when a partial command name matches more than one command in a registry.
+type AmbiguousCommandError struct {
+	// The names that lead to the registry which was searched.
+	Path []string
+	// The partial command name given.
+	Name string
+	// The commands the partial name matches.
+	Matches []*Entry
+}
+
+func (e AmbiguousCommandError) Error() string {
+	names := make([]string, len(e.Matches))
+	for i, entry := range e.Matches {
+		names[i] = entry.Name
+	}
+	return fmt.Sprintf("ambiguous command: %s (matches %s)", strings.Join(append(e.Path[:len(e.Path):len(e.Path)], e.Name), " "), strings.Join(names, ", "))
+}
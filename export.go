@@ -0,0 +1,262 @@
+package cmdgo
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// An exporter that can write a captured command so it can be imported later.
+type CaptureExporter func(source any) ([]byte, error)
+
+// An exporter that can be given as an argument followed by a path, ex: --save-json path/to/file.json
+type Exporter struct {
+	// The name of the exporter, the argument name is this with the ArgExportPrefix.
+	Name string
+	// Returns the data to write to the file for the command.
+	Export CaptureExporter
+}
+
+// The exporters available by name. The exporters given in Options.Exporters by default are these
+// in the order of DefaultExporterNames followed by any others in alphabetical order.
+var CaptureExports = map[string]CaptureExporter{
+	"json": func(source any) ([]byte, error) {
+		return json.MarshalIndent(source, "", "  ")
+	},
+	"yaml": func(source any) ([]byte, error) {
+		return yaml.Marshal(source)
+	},
+	"xml": func(source any) ([]byte, error) {
+		return xml.MarshalIndent(source, "", "  ")
+	},
+	"toml": func(source any) ([]byte, error) {
+		out := bytes.Buffer{}
+		err := toml.NewEncoder(&out).Encode(source)
+		return out.Bytes(), err
+	},
+}
+
+// The order of the built-in exporters in Options.Exporters.
+var DefaultExporterNames = []string{"json", "yaml", "xml", "toml"}
+
+// Returns the exporters in CaptureExports in the order of DefaultExporterNames
+// followed by any others in alphabetical order.
+func DefaultExporters() []Exporter {
+	exporters := make([]Exporter, 0, len(CaptureExports))
+	added := make(map[string]bool)
+	for _, name := range DefaultExporterNames {
+		if exporter, exists := CaptureExports[name]; exists {
+			exporters = append(exporters, Exporter{Name: name, Export: exporter})
+			added[name] = true
+		}
+	}
+	for _, name := range sortedKeys(CaptureExports) {
+		if !added[name] {
+			exporters = append(exporters, Exporter{Name: name, Export: CaptureExports[name]})
+		}
+	}
+	return exporters
+}
+
+// Returns a copy of the value to export without the properties with hidden input (`prompt-options:"hidden"`),
+// including properties of nested structs, slices, arrays, and maps. A struct with hidden properties is copied
+// to a struct with the same fields and tags except the hidden ones, so importing the export doesn't replace
+// hidden values with empty ones. A struct which marshals itself (ex: json.Marshaler) keeps its type so it's exported
+// the way it marshals itself, and its hidden properties have their zero value. The given value is unchanged.
+func ExportValue(value any) any {
+	if value == nil {
+		return nil
+	}
+	reflected := reflect.ValueOf(value)
+	return exportValue(reflected, exportRootType(reflected.Type())).Interface()
+}
+
+// Returns whether the struct field is a property with hidden input.
+func isHiddenField(field reflect.StructField) bool {
+	return getStructProperty(field, reflect.Value{}).InputHidden
+}
+
+// The interfaces of types which marshal themselves, which are exported with their own type.
+var exportMarshalers = []reflect.Type{
+	typeOf[json.Marshaler](),
+	typeOf[yaml.Marshaler](),
+	typeOf[xml.Marshaler](),
+	typeOf[toml.Marshaler](),
+	typeOf[encoding.TextMarshaler](),
+}
+
+// Returns whether the type or a pointer to it marshals itself for any of the exporters.
+func isMarshaler(typ reflect.Type) bool {
+	for _, marshaler := range exportMarshalers {
+		if typ.Implements(marshaler) || reflect.PointerTo(typ).Implements(marshaler) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the type the given type is exported as. It's the same type unless it has a struct with hidden properties,
+// then it's the type with those structs replaced by structs without the hidden properties. A struct that contains
+// itself or marshals itself keeps its type and the hidden properties in it are exported with their zero value.
+func exportType(typ reflect.Type, visiting map[reflect.Type]bool) reflect.Type {
+	switch typ.Kind() {
+	case reflect.Pointer:
+		if elem := exportType(typ.Elem(), visiting); elem != typ.Elem() {
+			return reflect.PointerTo(elem)
+		}
+	case reflect.Slice:
+		if elem := exportType(typ.Elem(), visiting); elem != typ.Elem() {
+			return reflect.SliceOf(elem)
+		}
+	case reflect.Array:
+		if elem := exportType(typ.Elem(), visiting); elem != typ.Elem() {
+			return reflect.ArrayOf(typ.Len(), elem)
+		}
+	case reflect.Map:
+		if elem := exportType(typ.Elem(), visiting); elem != typ.Elem() {
+			return reflect.MapOf(typ.Key(), elem)
+		}
+	case reflect.Struct:
+		if visiting[typ] || isMarshaler(typ) {
+			return typ
+		}
+		visiting[typ] = true
+		defer delete(visiting, typ)
+
+		fields := make([]reflect.StructField, 0, typ.NumField())
+		changed := false
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if isHiddenField(field) {
+				changed = true
+				continue
+			}
+			if fieldType := exportType(field.Type, visiting); fieldType != field.Type {
+				field.Type = fieldType
+				changed = true
+			}
+			fields = append(fields, reflect.StructField{Name: field.Name, Type: field.Type, Tag: field.Tag, Anonymous: field.Anonymous})
+		}
+		if changed {
+			return structOf(typ, fields)
+		}
+	}
+	return typ
+}
+
+// Returns the type the given type is exported as when it's the value being exported. If it's a struct replaced by
+// exportType the replacement is given the XML element name of the struct, since the replacement has no name.
+func exportRootType(typ reflect.Type) reflect.Type {
+	exported := exportType(typ, map[reflect.Type]bool{})
+	if exported == typ {
+		return exported
+	}
+	if typ.Kind() == reflect.Pointer {
+		return reflect.PointerTo(exportRootType(typ.Elem()))
+	}
+	if typ.Kind() != reflect.Struct {
+		return exported
+	}
+	if _, exists := exported.FieldByName("XMLName"); exists {
+		return exported
+	}
+	fields := []reflect.StructField{{
+		Name: "XMLName",
+		Type: reflect.TypeOf(xml.Name{}),
+		Tag:  reflect.StructTag(fmt.Sprintf(`xml:"%s" json:"-" yaml:"-" toml:"-"`, typ.Name())),
+	}}
+	for i := 0; i < exported.NumField(); i++ {
+		fields = append(fields, exported.Field(i))
+	}
+	return structOf(typ, fields)
+}
+
+// Returns a struct type with the given fields, or the given type if a struct can't be created with them.
+func structOf(typ reflect.Type, fields []reflect.StructField) (created reflect.Type) {
+	defer func() {
+		if recover() != nil {
+			created = typ
+		}
+	}()
+	return reflect.StructOf(fields)
+}
+
+// Returns a copy of the value converted to the given type returned by exportType.
+func exportValue(value reflect.Value, typ reflect.Type) reflect.Value {
+	switch value.Kind() {
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		elem := value.Elem()
+		exported := reflect.New(typ).Elem()
+		exported.Set(exportValue(elem, exportType(elem.Type(), map[reflect.Type]bool{})))
+		return exported
+	case reflect.Pointer:
+		if value.IsNil() {
+			return reflect.Zero(typ)
+		}
+		exported := reflect.New(typ.Elem())
+		exported.Elem().Set(exportValue(value.Elem(), typ.Elem()))
+		return exported
+	case reflect.Struct:
+		same := typ == value.Type()
+		exported := reflect.New(typ).Elem()
+		if same {
+			exported.Set(value)
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if same && isHiddenField(field) {
+				exported.Field(i).Set(reflect.Zero(field.Type))
+				continue
+			}
+			fieldValue := value.FieldByName(field.Name)
+			if same {
+				fieldValue = value.Field(i)
+			}
+			if fieldValue.IsValid() {
+				exported.Field(i).Set(exportValue(fieldValue, field.Type))
+			}
+		}
+		return exported
+	case reflect.Slice:
+		if value.IsNil() {
+			return reflect.Zero(typ)
+		}
+		exported := reflect.MakeSlice(typ, value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			exported.Index(i).Set(exportValue(value.Index(i), typ.Elem()))
+		}
+		return exported
+	case reflect.Array:
+		exported := reflect.New(typ).Elem()
+		for i := 0; i < value.Len(); i++ {
+			exported.Index(i).Set(exportValue(value.Index(i), typ.Elem()))
+		}
+		return exported
+	case reflect.Map:
+		if value.IsNil() {
+			return reflect.Zero(typ)
+		}
+		exported := reflect.MakeMapWithSize(typ, value.Len())
+		itr := value.MapRange()
+		for itr.Next() {
+			exported.SetMapIndex(itr.Key(), exportValue(itr.Value(), typ.Elem()))
+		}
+		return exported
+	}
+	return value
+}
@@ -0,0 +1,170 @@
+package cmdgo
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+type ExportCredentials struct {
+	User     string
+	Password string `prompt-options:"hidden"`
+}
+
+type ExportCommand struct {
+	Name   string
+	Token  string `prompt-options:"hidden"`
+	Login  ExportCredentials
+	Others []*ExportCredentials
+}
+
+func TestExport(t *testing.T) {
+	for _, format := range DefaultExporterNames {
+		path := t.TempDir() + "/saved." + format
+
+		registry := CreateRegistry([]Entry{
+			{Name: "export", Command: ExportCommand{}},
+		})
+
+		opts := NewOptions().WithArgs([]string{
+			"export", "--name", "app", "--token", "secret",
+			"--login-user", "admin", "--login-password", "hunter2",
+			"--others-1-user", "guest", "--others-1-password", "guest2",
+			"--save-" + format, path,
+		})
+		cmd, err := registry.Capture(opts)
+		if err != nil {
+			t.Fatalf("Test [%s] failed with error %v", format, err)
+		}
+		captured := cmd.(*ExportCommand)
+		if captured.Token != "secret" || captured.Login.Password != "hunter2" || captured.Others[0].Password != "guest2" {
+			t.Errorf("Test [%s] exporting changed the captured command %s", format, toJson(captured))
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Test [%s] failed with error %v", format, err)
+		}
+		for _, hidden := range []string{"token", "password"} {
+			if strings.Contains(strings.ToLower(string(data)), hidden) {
+				t.Errorf("Test [%s] expected %s to be left out of the export but got:\n%s", format, hidden, data)
+			}
+		}
+		replayed := ExportCommand{}
+		err = CaptureImports[format](data, &replayed)
+		if err != nil {
+			t.Fatalf("Test [%s] failed to import with error %v", format, err)
+		}
+
+		expected := ExportCommand{
+			Name:   "app",
+			Login:  ExportCredentials{User: "admin"},
+			Others: []*ExportCredentials{{User: "guest"}},
+		}
+		if !equalsJson(replayed, expected) {
+			t.Errorf("Test [%s] expected %s but got %s", format, toJson(expected), toJson(replayed))
+		}
+	}
+}
+
+func TestExportLayered(t *testing.T) {
+	for _, format := range DefaultExporterNames {
+		dir := t.TempDir()
+		base := dir + "/base." + format
+		saved := dir + "/saved." + format
+
+		registry := CreateRegistry([]Entry{
+			{Name: "export", Command: ExportCommand{}},
+		})
+
+		data, err := CaptureExports[format](&ExportCommand{Token: "secret", Login: ExportCredentials{Password: "hunter2"}})
+		if err != nil {
+			t.Fatalf("Test [%s] failed with error %v", format, err)
+		}
+		err = os.WriteFile(base, data, 0644)
+		if err != nil {
+			t.Fatalf("Test [%s] failed with error %v", format, err)
+		}
+
+		opts := NewOptions().WithArgs([]string{
+			"export", "--name", "app", "--login-user", "admin", "--save-" + format, saved,
+		})
+		_, err = registry.Capture(opts)
+		if err != nil {
+			t.Fatalf("Test [%s] failed with error %v", format, err)
+		}
+
+		opts = NewOptions().WithArgs([]string{"export", "--" + format, base, "--" + format, saved})
+		cmd, err := registry.Capture(opts)
+		if err != nil {
+			t.Fatalf("Test [%s] failed with error %v", format, err)
+		}
+
+		captured := cmd.(*ExportCommand)
+		expected := ExportCommand{
+			Name:  "app",
+			Token: "secret",
+			Login: ExportCredentials{User: "admin", Password: "hunter2"},
+		}
+		if captured.Name != expected.Name || captured.Token != expected.Token || captured.Login != expected.Login {
+			t.Errorf("Test [%s] expected %s but got %s", format, toJson(expected), toJson(captured))
+		}
+	}
+}
+
+type ExportMarshaler struct {
+	A      string
+	Secret string `prompt-options:"hidden"`
+}
+
+func (em ExportMarshaler) MarshalJSON() ([]byte, error) {
+	if em.Secret != "" {
+		return []byte(`"leaked"`), nil
+	}
+	return []byte(`"custom"`), nil
+}
+
+type ExportMarshalerCommand struct {
+	Name  string
+	Inner ExportMarshaler
+}
+
+func TestExportMarshaler(t *testing.T) {
+	exported := ExportValue(&ExportMarshalerCommand{Name: "x", Inner: ExportMarshaler{A: "a", Secret: "s"}})
+
+	data, err := CaptureExports["json"](exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n  \"Name\": \"x\",\n  \"Inner\": \"custom\"\n}"
+	if string(data) != expected {
+		t.Errorf("Expected %s but got %s", expected, data)
+	}
+}
+
+func TestExportArgAddedLater(t *testing.T) {
+	path := t.TempDir() + "/saved.txt"
+
+	registry := CreateRegistry([]Entry{
+		{Name: "simple", Command: SimpleCommand{}},
+	})
+
+	opts := NewOptions().WithArgs([]string{"simple", "--message", "app", "--save-text", path})
+
+	CaptureExports["text"] = func(source any) ([]byte, error) {
+		return []byte(source.(*SimpleCommand).Message), nil
+	}
+	defer delete(CaptureExports, "text")
+
+	_, err := registry.Capture(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "app" {
+		t.Errorf("Expected app to be exported but got %s", data)
+	}
+}
@@ -26,3 +26,13 @@ func Capture(opts *Options) (any, error) {
 func Peek(opts *Options) any {
 	return GlobalRegistry.Peek(opts)
 }
+
+// Adds the completion command to the global registry. See Registry.AddCompletion.
+func RegisterCompletion() {
+	GlobalRegistry.AddCompletion()
+}
+
+// Adds the shell command to the global registry. See Registry.AddShell.
+func RegisterShell() {
+	GlobalRegistry.AddShell()
+}
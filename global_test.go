@@ -2,7 +2,9 @@ package cmdgo
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
@@ -702,3 +704,876 @@ func toJson(a any) string {
 func ptrTo[T any](value T) *T {
 	return &value
 }
+
+func TestStrictArgs(t *testing.T) {
+	registry := CreateRegistry([]Entry{
+		{Name: "simple", Command: SimpleCommand{}},
+	})
+
+	tests := []struct {
+		name      string
+		args      []string
+		strict    bool
+		errorText string
+	}{
+		{
+			name:   "not strict",
+			args:   []string{"simple", "--mesage", "hi"},
+			strict: false,
+		},
+		{
+			name:   "strict valid",
+			args:   []string{"simple", "--message", "hi"},
+			strict: true,
+		},
+		{
+			name:      "strict typo",
+			args:      []string{"simple", "--mesage", "hi"},
+			strict:    true,
+			errorText: "unknown arguments: --mesage (did you mean --message?), hi",
+		},
+		{
+			name:      "strict leftover",
+			args:      []string{"simple", "--message", "hi", "--jsn", "x.json"},
+			strict:    true,
+			errorText: "unknown arguments: --jsn (did you mean --json?), x.json",
+		},
+	}
+
+	for _, test := range tests {
+		opts := NewOptions().WithArgs(test.args)
+		opts.StrictArgs = test.strict
+
+		_, err := registry.Capture(opts)
+		if err != nil {
+			if test.errorText == "" {
+				t.Errorf("Test [%s] failed with error %v", test.name, err)
+			} else if _, ok := err.(UnknownArgsError); !ok {
+				t.Errorf("Test [%s] expected UnknownArgsError but got %T", test.name, err)
+			} else if err.Error() != test.errorText {
+				t.Errorf("Test [%s] expected error %s but got %s", test.name, test.errorText, err.Error())
+			}
+		} else if test.errorText != "" {
+			t.Errorf("Test [%s] expected error %s", test.name, test.errorText)
+		}
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	registry := CreateRegistry([]Entry{
+		{Name: "config", HelpShort: "Configures", Command: SimpleCommand{}},
+		{Name: "connect", Aliases: []string{"conn"}, HelpShort: "Connects", Command: SimpleCommand{}},
+		{Name: "sub", Sub: CreateRegistry([]Entry{
+			{Name: "simple", Command: SimpleCommand{}},
+			{Name: "second", Command: SimpleCommand{}},
+		})},
+	})
+
+	tests := []struct {
+		name      string
+		args      []string
+		errorText string
+		help      string
+	}{
+		{
+			name:      "ambiguous",
+			args:      []string{"con"},
+			errorText: "ambiguous command: con (matches config, connect)",
+			help:      "con is ambiguous, it matches the commands:\nconfig   Configures\nconnect  Connects\n",
+		},
+		{
+			name:      "not found",
+			args:      []string{"confg"},
+			errorText: "command not found: confg (did you mean config or conn?)",
+			help:      "confg is not a valid command. Did you mean config or conn?\n",
+		},
+		{
+			name:      "not found sub",
+			args:      []string{"sub", "simpel"},
+			errorText: "command not found: sub simpel (did you mean simple?)",
+			help:      "simpel is not a valid command. Did you mean simple?\n",
+		},
+		{
+			name:      "not found no suggestions",
+			args:      []string{"sub", "xyz"},
+			errorText: "command not found: sub xyz",
+			help:      "xyz is not a valid command. Valid commands:\nsimple  \nsecond  \n",
+		},
+		{
+			name:      "missing sub",
+			args:      []string{"sub"},
+			errorText: "missing command after: sub (did you mean simple or second?)",
+			help:      "sub requires a command. Valid commands:\nsimple  \nsecond  \n",
+		},
+	}
+
+	for _, test := range tests {
+		out, err := os.CreateTemp("", "help")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(out.Name())
+
+		opts := NewOptions().WithArgs(test.args)
+		opts.WithFiles(nil, out)
+
+		_, err = registry.Capture(opts)
+		if err == nil {
+			t.Errorf("Test [%s] expected error %s", test.name, test.errorText)
+			continue
+		} else if err.Error() != test.errorText {
+			t.Errorf("Test [%s] expected error %s but got %s", test.name, test.errorText, err.Error())
+		}
+
+		DisplayCommandError(opts, registry, err)
+
+		printed, _ := os.ReadFile(out.Name())
+		if string(printed) != test.help {
+			t.Errorf("Test [%s] expected help %q but got %q", test.name, test.help, printed)
+		}
+	}
+}
+
+type PositionalCommand struct {
+	Source  string   `pos:"1"`
+	Targets []string `pos:"rest"`
+	Verbose bool
+	Mode    string `options:"fast,safe"`
+}
+
+func TestPositional(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		prompts  []string
+		expected PositionalCommand
+		leftover []string
+	}{
+		{
+			name:     "source only",
+			args:     []string{"a"},
+			expected: PositionalCommand{Source: "a"},
+		},
+		{
+			name:     "source and targets",
+			args:     []string{"a", "b", "c"},
+			expected: PositionalCommand{Source: "a", Targets: []string{"b", "c"}},
+		},
+		{
+			name:     "mixed with flags",
+			args:     []string{"--verbose", "a", "--mode", "safe", "b"},
+			expected: PositionalCommand{Source: "a", Targets: []string{"b"}, Verbose: true, Mode: "safe"},
+		},
+		{
+			name:     "bool with value",
+			args:     []string{"--verbose", "false", "a"},
+			expected: PositionalCommand{Source: "a"},
+		},
+		{
+			name:     "named instead",
+			args:     []string{"--source", "a", "--mode", "fast"},
+			expected: PositionalCommand{Source: "a", Mode: "fast"},
+		},
+		{
+			name:     "prompt missing",
+			args:     []string{"--mode", "fast"},
+			prompts:  []string{"Source: a", "Targets? (y/n): n", "Verbose: false", "Mode (fast): "},
+			expected: PositionalCommand{Source: "a", Mode: "fast"},
+		},
+		{
+			name:     "leftover",
+			args:     []string{"a", "--unknown", "x"},
+			expected: PositionalCommand{Source: "a"},
+			leftover: []string{"--unknown", "x"},
+		},
+	}
+
+	for _, test := range tests {
+		opts := NewOptions().WithArgs(test.args)
+		opts.ForcePrompt = len(test.prompts) > 0
+		opts.PromptOnce = func(prompt string, options PromptOnceOptions) (string, error) {
+			if len(test.prompts) == 0 {
+				return "", fmt.Errorf("No input left for prompt '%s'", prompt)
+			}
+			line := test.prompts[0]
+			test.prompts = test.prompts[1:]
+			if strings.HasPrefix(line, prompt) {
+				return line[len(prompt):], nil
+			} else {
+				return "", fmt.Errorf("Prompted '%s', got '%s'", prompt, line)
+			}
+		}
+
+		actual := PositionalCommand{}
+		err := Unmarshal(opts, &actual)
+
+		if err != nil {
+			t.Errorf("Test [%s] failed with error %v", test.name, err)
+		} else if !equalsJson(actual, test.expected) {
+			t.Errorf("Test [%s] failed, expected %+v got %+v", test.name, toJson(test.expected), toJson(actual))
+		} else if !equalsJson(opts.Args, test.leftover) && !(len(opts.Args) == 0 && len(test.leftover) == 0) {
+			t.Errorf("Test [%s] failed, expected leftover %v got %v", test.name, test.leftover, opts.Args)
+		}
+	}
+}
+
+func TestPositionalUsage(t *testing.T) {
+	out, err := os.CreateTemp("", "help")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(out.Name())
+
+	opts := NewOptions()
+	opts.WithFiles(nil, out)
+
+	err = DisplayEntryHelp(opts, &Entry{Name: "copy", Command: PositionalCommand{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	printed, _ := os.ReadFile(out.Name())
+	expected := "copy:\n  Usage: copy [options] <source> [targets...]\n"
+	if !strings.HasPrefix(string(printed), expected) {
+		t.Errorf("Expected help to start with %q but got %q", expected, printed)
+	}
+}
+
+type ShortCommand struct {
+	All     bool   `short:"a"`
+	Brief   bool   `short:"b"`
+	Output  string `short:"o"`
+	Offset  int
+	Files   []string `pos:"rest"`
+	Verbose bool
+}
+
+func TestShortArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected ShortCommand
+		leftover []string
+	}{
+		{
+			name:     "single",
+			args:     []string{"-a"},
+			expected: ShortCommand{All: true},
+		},
+		{
+			name:     "bundled",
+			args:     []string{"-ab", "-o", "out.txt"},
+			expected: ShortCommand{All: true, Brief: true, Output: "out.txt"},
+		},
+		{
+			name:     "bundled value",
+			args:     []string{"-aboout.txt"},
+			expected: ShortCommand{All: true, Brief: true, Output: "out.txt"},
+		},
+		{
+			name:     "short equals",
+			args:     []string{"-o=out.txt", "-a=false"},
+			expected: ShortCommand{Output: "out.txt"},
+		},
+		{
+			name:     "long equals",
+			args:     []string{"--output=--x=1", "--offset=-3"},
+			expected: ShortCommand{Output: "--x=1", Offset: -3},
+		},
+		{
+			name:     "negative number",
+			args:     []string{"--offset", "-3", "-a"},
+			expected: ShortCommand{All: true, Offset: -3},
+		},
+		{
+			name:     "terminator",
+			args:     []string{"-a", "x", "--", "-b", "--verbose"},
+			expected: ShortCommand{All: true, Files: []string{"x", "-b", "--verbose"}},
+		},
+		{
+			name:     "unknown short",
+			args:     []string{"-az"},
+			expected: ShortCommand{},
+			leftover: []string{"-az"},
+		},
+	}
+
+	for _, test := range tests {
+		opts := NewOptions().WithArgs(test.args)
+
+		actual := ShortCommand{}
+		err := Unmarshal(opts, &actual)
+
+		if err != nil {
+			t.Errorf("Test [%s] failed with error %v", test.name, err)
+		} else if !equalsJson(actual, test.expected) {
+			t.Errorf("Test [%s] failed, expected %+v got %+v", test.name, toJson(test.expected), toJson(actual))
+		} else if !equalsJson(opts.Args, test.leftover) && !(len(opts.Args) == 0 && len(test.leftover) == 0) {
+			t.Errorf("Test [%s] failed, expected leftover %v got %v", test.name, test.leftover, opts.Args)
+		}
+	}
+}
+
+type FlagCommand struct {
+	Verbose   int      `short:"v" count:"true"`
+	Color     bool     `default:"true"`
+	Recursive bool     `short:"r"`
+	Files     []string `pos:"rest"`
+}
+
+func TestNegatableAndCountedFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected FlagCommand
+	}{
+		{
+			name:     "defaults",
+			args:     []string{},
+			expected: FlagCommand{Color: true},
+		},
+		{
+			name:     "negated",
+			args:     []string{"--no-color", "a.txt"},
+			expected: FlagCommand{Color: false, Files: []string{"a.txt"}},
+		},
+		{
+			name:     "negated false",
+			args:     []string{"--no-color=false"},
+			expected: FlagCommand{Color: true},
+		},
+		{
+			name:     "counted long",
+			args:     []string{"--verbose", "a.txt", "--verbose"},
+			expected: FlagCommand{Verbose: 2, Color: true, Files: []string{"a.txt"}},
+		},
+		{
+			name:     "counted short",
+			args:     []string{"-vvv", "-r"},
+			expected: FlagCommand{Verbose: 3, Color: true, Recursive: true},
+		},
+		{
+			name:     "counted bundled",
+			args:     []string{"-vrv", "--verbose=2"},
+			expected: FlagCommand{Verbose: 4, Color: true, Recursive: true},
+		},
+	}
+
+	for _, test := range tests {
+		opts := NewOptions().WithArgs(test.args)
+
+		actual := FlagCommand{}
+		err := Unmarshal(opts, &actual)
+
+		if err != nil {
+			t.Errorf("Test [%s] failed with error %v", test.name, err)
+		} else if !equalsJson(actual, test.expected) {
+			t.Errorf("Test [%s] failed, expected %+v got %+v", test.name, toJson(test.expected), toJson(actual))
+		} else if len(opts.Args) > 0 {
+			t.Errorf("Test [%s] failed, unexpected leftover %v", test.name, opts.Args)
+		}
+	}
+}
+
+func TestArgFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"simple.args":   "simple @" + dir + "/message.args",
+		"message.args":  "# the message to use\n--message 'hello world'",
+		"escaped.args":  "simple --message @@user",
+		"cycle.args":    "simple @" + dir + "/cycle2.args",
+		"cycle2.args":   "@" + dir + "/cycle.args",
+		"unclosed.args": "simple --message 'hello",
+	}
+	for name, content := range files {
+		err := os.WriteFile(dir+"/"+name, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry := CreateRegistry([]Entry{
+		{Name: "simple", Command: SimpleCommand{}},
+	})
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		err      error
+	}{
+		{
+			name:     "nested",
+			args:     []string{"@" + dir + "/simple.args"},
+			expected: "hello world",
+		},
+		{
+			name:     "after command",
+			args:     []string{"simple", "@" + dir + "/message.args"},
+			expected: "hello world",
+		},
+		{
+			name:     "escaped",
+			args:     []string{"@" + dir + "/escaped.args"},
+			expected: "@user",
+		},
+		{
+			name: "cycle",
+			args: []string{"@" + dir + "/cycle.args"},
+			err:  ErrArgFileCycle,
+		},
+		{
+			name: "unclosed",
+			args: []string{"@" + dir + "/unclosed.args"},
+			err:  ErrUnclosedQuote,
+		},
+		{
+			name: "missing",
+			args: []string{"@" + dir + "/missing.args"},
+			err:  os.ErrNotExist,
+		},
+	}
+
+	for _, test := range tests {
+		opts := NewOptions().WithArgs(test.args)
+		opts.ArgFiles = true
+
+		cmd, err := registry.Capture(opts)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("Test [%s] expected error %v but got %v", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test [%s] failed with error %v", test.name, err)
+		} else if simple, ok := cmd.(*SimpleCommand); !ok || simple.Message != test.expected {
+			t.Errorf("Test [%s] expected message %s but got %+v", test.name, test.expected, cmd)
+		}
+	}
+}
+
+type PrecedenceCommand struct {
+	Name  string `default:"default" json:"name" yaml:"name"`
+	Level string `json:"level" yaml:"level"`
+	Port  int    `env:"PRECEDENCE_PORT" json:"port" yaml:"port"`
+}
+
+func TestPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.json": `{"name": "json", "port": 1}`,
+		"b.yaml": "name: yaml\nlevel: info",
+		"c.json": `{"level": "debug"}`,
+	}
+	for name, content := range files {
+		err := os.WriteFile(dir+"/"+name, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry := CreateRegistry([]Entry{
+		{Name: "prec", Command: PrecedenceCommand{}},
+	})
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		expected PrecedenceCommand
+	}{
+		{
+			name:     "defaults",
+			args:     []string{"--interactive", "false"},
+			expected: PrecedenceCommand{Name: "default"},
+		},
+		{
+			name:     "file over default",
+			args:     []string{"--json", dir + "/a.json"},
+			expected: PrecedenceCommand{Name: "json", Port: 1},
+		},
+		{
+			name:     "files in argument order",
+			args:     []string{"--json", dir + "/a.json", "--yaml", dir + "/b.yaml"},
+			expected: PrecedenceCommand{Name: "yaml", Level: "info", Port: 1},
+		},
+		{
+			name:     "files in reverse argument order",
+			args:     []string{"--yaml", dir + "/b.yaml", "--json=" + dir + "/a.json"},
+			expected: PrecedenceCommand{Name: "json", Level: "info", Port: 1},
+		},
+		{
+			name:     "same importer merged",
+			args:     []string{"--json", dir + "/a.json", "--json", dir + "/c.json"},
+			expected: PrecedenceCommand{Name: "json", Level: "debug", Port: 1},
+		},
+		{
+			name:     "env over file",
+			args:     []string{"--json", dir + "/a.json"},
+			env:      "2",
+			expected: PrecedenceCommand{Name: "json", Port: 2},
+		},
+		{
+			name:     "args over env",
+			args:     []string{"--json", dir + "/a.json", "--port", "3", "--name", "arg"},
+			env:      "2",
+			expected: PrecedenceCommand{Name: "arg", Port: 3},
+		},
+	}
+
+	for _, test := range tests {
+		os.Setenv("PRECEDENCE_PORT", test.env)
+
+		opts := NewOptions().WithArgs(append([]string{"prec"}, test.args...))
+		cmd, err := registry.Capture(opts)
+		if err != nil {
+			t.Errorf("Test [%s] failed with error %v", test.name, err)
+		} else if !equalsJson(cmd, test.expected) {
+			t.Errorf("Test [%s] failed, expected %+v got %+v", test.name, toJson(test.expected), toJson(cmd))
+		}
+	}
+
+	os.Unsetenv("PRECEDENCE_PORT")
+}
+
+func TestImportInput(t *testing.T) {
+	in, err := os.CreateTemp("", "input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(in.Name())
+	in.WriteString(`{"name": "piped"}`)
+	in.Seek(0, 0)
+
+	registry := CreateRegistry([]Entry{
+		{Name: "prec", Command: PrecedenceCommand{}},
+	})
+
+	opts := NewOptions().WithArgs([]string{"prec", "--json", "-"})
+	opts.WithFiles(in, nil)
+	opts.PromptOnce = func(prompt string, options PromptOnceOptions) (string, error) {
+		return "", fmt.Errorf("Unexpected prompt '%s'", prompt)
+	}
+
+	cmd, err := registry.Capture(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := PrecedenceCommand{Name: "piped"}
+	if !equalsJson(cmd, expected) {
+		t.Errorf("Expected %+v got %+v", toJson(expected), toJson(cmd))
+	}
+	if opts.DisablePrompt {
+		t.Errorf("Expected prompting to be restored after capture")
+	}
+
+	opts = NewOptions().WithArgs([]string{"prec", "--json", "-"})
+	_, err = registry.Capture(opts)
+	if err != ErrNoInput {
+		t.Errorf("Expected ErrNoInput but got %v", err)
+	}
+}
+
+type FileValueCommand struct {
+	Password string `env:"FILE_VALUE_PASSWORD"`
+	Cert     string `from-file:"true" env:"FILE_VALUE_CERT"`
+	Key      []byte `from-file:"true"`
+	Name     string `from-file:"true"`
+	Owner    string `from-file:"true" default:"@owner"`
+}
+
+func TestFileValues(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"password": "secret\n",
+		"cert.pem": "CERT\n",
+		"key.pem":  "KEY",
+	}
+	for name, contents := range files {
+		err := os.WriteFile(dir+"/"+name, []byte(contents), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		env           map[string]string
+		expected      FileValueCommand
+		expectedError error
+	}{
+		{
+			name:     "env file",
+			env:      map[string]string{"FILE_VALUE_PASSWORD_FILE": dir + "/password"},
+			expected: FileValueCommand{Password: "secret", Owner: "@owner"},
+		},
+		{
+			name:     "env before env file",
+			env:      map[string]string{"FILE_VALUE_PASSWORD": "direct", "FILE_VALUE_PASSWORD_FILE": dir + "/password"},
+			expected: FileValueCommand{Password: "direct", Owner: "@owner"},
+		},
+		{
+			name:          "env file missing",
+			env:           map[string]string{"FILE_VALUE_PASSWORD_FILE": dir + "/missing"},
+			expectedError: os.ErrNotExist,
+		},
+		{
+			name:     "from file",
+			args:     []string{"--cert", "@" + dir + "/cert.pem", "--key=@" + dir + "/key.pem", "--name", "@@home"},
+			expected: FileValueCommand{Cert: "CERT\n", Key: []byte("KEY"), Name: "@home", Owner: "@owner"},
+		},
+		{
+			name:     "from file literal",
+			args:     []string{"--cert", "text", "--key", "bytes"},
+			expected: FileValueCommand{Cert: "text", Key: []byte("bytes"), Owner: "@owner"},
+		},
+		{
+			name:     "env and default literal",
+			env:      map[string]string{"FILE_VALUE_CERT": "@" + dir + "/cert.pem"},
+			expected: FileValueCommand{Cert: "@" + dir + "/cert.pem", Owner: "@owner"},
+		},
+		{
+			name:     "from file overrides literal",
+			args:     []string{"--owner", "@" + dir + "/key.pem"},
+			expected: FileValueCommand{Owner: "KEY"},
+		},
+	}
+
+	for _, test := range tests {
+		for name, value := range test.env {
+			t.Setenv(name, value)
+		}
+
+		actual := FileValueCommand{}
+		err := Unmarshal(NewOptions().WithArgs(test.args), &actual)
+
+		for name := range test.env {
+			os.Unsetenv(name)
+		}
+
+		if err != nil {
+			if test.expectedError == nil {
+				t.Errorf("Test [%s] failed with error %v", test.name, err)
+			} else if !errors.Is(err, test.expectedError) {
+				t.Errorf("Test [%s] expected error %v but got %v", test.name, test.expectedError, err)
+			}
+		} else if test.expectedError != nil {
+			t.Errorf("Test [%s] expected error %v", test.name, test.expectedError)
+		} else if !equalsJson(actual, test.expected) {
+			t.Errorf("Test [%s] failed, expected %s got %s", test.name, toJson(test.expected), toJson(actual))
+		}
+	}
+}
+
+type FilePromptCommand struct {
+	Cert string `from-file:"true"`
+	Key  []byte `from-file:"true"`
+}
+
+func TestFileValuePrompt(t *testing.T) {
+	path := t.TempDir() + "/cert.pem"
+	err := os.WriteFile(path, []byte("CERT"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := []string{"@" + path, "@" + path}
+	opts := NewOptions()
+	opts.ForcePrompt = true
+	opts.PromptOnce = func(prompt string, options PromptOnceOptions) (string, error) {
+		if len(inputs) == 0 {
+			return "", fmt.Errorf("No input left for prompt '%s'", prompt)
+		}
+		input := inputs[0]
+		inputs = inputs[1:]
+		return input, nil
+	}
+
+	actual := FilePromptCommand{}
+	err = Unmarshal(opts, &actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := FilePromptCommand{Cert: "CERT", Key: []byte("CERT")}
+	if !equalsJson(actual, expected) {
+		t.Errorf("Expected %s got %s", toJson(expected), toJson(actual))
+	}
+}
+
+type InterpolationCommand struct {
+	Name  string
+	Cache string `default:"${INTERPOLATION_HOME}/.cache/{{ .Name }}"`
+	Port  int    `default:"{{ if .Name }}8080{{ else }}80{{ end }}"`
+	Cost  string `default:"$5 ${INTERPOLATION_MISSING}"`
+}
+
+func TestDefaultInterpolation(t *testing.T) {
+	t.Setenv("INTERPOLATION_HOME", "/home/me")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected InterpolationCommand
+	}{
+		{
+			name:     "properties",
+			args:     []string{"--name", "app"},
+			expected: InterpolationCommand{Name: "app", Cache: "/home/me/.cache/app", Port: 8080, Cost: "$5 "},
+		},
+		{
+			name:     "empty properties",
+			expected: InterpolationCommand{Cache: "/home/me/.cache/", Port: 80, Cost: "$5 "},
+		},
+		{
+			name:     "args",
+			args:     []string{"--name", "app", "--cache", "/tmp", "--port", "1"},
+			expected: InterpolationCommand{Name: "app", Cache: "/tmp", Port: 1, Cost: "$5 "},
+		},
+	}
+
+	for _, test := range tests {
+		actual := InterpolationCommand{}
+		err := Unmarshal(NewOptions().WithArgs(test.args), &actual)
+		if err != nil {
+			t.Errorf("Test [%s] failed with error %v", test.name, err)
+		} else if !equalsJson(actual, test.expected) {
+			t.Errorf("Test [%s] failed, expected %s got %s", test.name, toJson(test.expected), toJson(actual))
+		}
+	}
+}
+
+func TestImportExpandEnv(t *testing.T) {
+	t.Setenv("IMPORT_EXPAND_NAME", "expanded")
+
+	path := t.TempDir() + "/import.json"
+	err := os.WriteFile(path, []byte(`{"name": "${IMPORT_EXPAND_NAME}"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := CreateRegistry([]Entry{
+		{Name: "prec", Command: PrecedenceCommand{}},
+	})
+
+	for _, expand := range []bool{false, true} {
+		opts := NewOptions().WithArgs([]string{"prec", "--json", path})
+		opts.ImportExpandEnv = expand
+
+		cmd, err := registry.Capture(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "${IMPORT_EXPAND_NAME}"
+		if expand {
+			expected = "expanded"
+		}
+		if name := cmd.(*PrecedenceCommand).Name; name != expected {
+			t.Errorf("Test [expand %v] expected name %s but got %s", expand, expected, name)
+		}
+	}
+}
+
+type EnvAutoServer struct {
+	Host string
+	Port int `env:"auto"`
+}
+
+type EnvAutoCommand struct {
+	Name    string `env:"auto"`
+	Level   string `env:"ENV_AUTO_LEVEL,auto"`
+	DB      EnvAutoServer
+	Servers []EnvAutoServer
+	Plain   string
+}
+
+func TestEnvAuto(t *testing.T) {
+	t.Setenv("APP_NAME", "app")
+	t.Setenv("APP_LEVEL", "auto")
+	t.Setenv("ENV_AUTO_LEVEL", "explicit")
+	t.Setenv("APP_DB_HOST", "db.local")
+	t.Setenv("APP_DB_PORT", "5432")
+	t.Setenv("APP_SERVERS_1_PORT", "8080")
+	t.Setenv("APP_SERVERS_2_PORT", "8081")
+	t.Setenv("APP_PLAIN", "plain")
+
+	tests := []struct {
+		name     string
+		auto     bool
+		expected EnvAutoCommand
+	}{
+		{
+			name: "tagged",
+			expected: EnvAutoCommand{
+				Name:    "app",
+				Level:   "explicit",
+				DB:      EnvAutoServer{Port: 5432},
+				Servers: []EnvAutoServer{{Port: 8080}, {Port: 8081}},
+			},
+		},
+		{
+			name: "all",
+			auto: true,
+			expected: EnvAutoCommand{
+				Name:    "app",
+				Level:   "explicit",
+				DB:      EnvAutoServer{Host: "db.local", Port: 5432},
+				Servers: []EnvAutoServer{{Port: 8080}, {Port: 8081}},
+				Plain:   "plain",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		opts := NewOptions()
+		opts.EnvPrefix = "APP_"
+		opts.EnvAuto = test.auto
+
+		actual := EnvAutoCommand{}
+		err := Unmarshal(opts, &actual)
+		if err != nil {
+			t.Errorf("Test [%s] failed with error %v", test.name, err)
+		} else if !equalsJson(actual, test.expected) {
+			t.Errorf("Test [%s] failed, expected %s got %s", test.name, toJson(test.expected), toJson(actual))
+		}
+	}
+
+	// Only a value from the environment variable named after the path has the path flag.
+	opts := NewOptions()
+	opts.EnvPrefix = "APP_"
+	instance := GetInstance(&EnvAutoCommand{})
+	for name, expected := range map[string]bool{"Name": true, "Level": false} {
+		prop := instance.PropertyMap[Normalize(name)]
+		err := prop.Load(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := prop.Flags.Is(MatchAny(PropertyFlagEnvPath)); actual != expected {
+			t.Errorf("Test [%s] expected the env path flag to be %v but got %v", name, expected, actual)
+		}
+	}
+
+	out, err := os.CreateTemp("", "help")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(out.Name())
+
+	opts = NewOptions()
+	opts.EnvPrefix = "APP_"
+	opts.WithFiles(nil, out)
+
+	err = DisplayEntryHelp(opts, &Entry{Name: "auto", Command: EnvAutoCommand{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	printed, _ := os.ReadFile(out.Name())
+	for _, expected := range []string{"APP_NAME", "ENV_AUTO_LEVEL APP_LEVEL", "APP_DB_PORT", "APP_SERVERS_1_PORT"} {
+		if !strings.Contains(string(printed), "environment variables: "+expected) {
+			t.Errorf("Expected help to contain %s but got %s", expected, printed)
+		}
+	}
+}
@@ -3,6 +3,7 @@ module github.com/ClickerMonkey/cmdgo
 go 1.19
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	golang.org/x/exp v0.0.0-20221004215720-b9f4876ce741
 	golang.org/x/term v0.0.0-20220919170432-7a66f970e087
 	gopkg.in/yaml.v2 v2.4.0
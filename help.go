@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 )
 
 type helpTemplate struct {
-	Options   *Options
-	Prop      Property
-	ArgPrefix string
-	Arg       string
+	Options    *Options
+	Prop       Property
+	ArgPrefix  string
+	Arg        string
+	NegatedArg string
+	PathEnv    string
 }
 
 func (ht helpTemplate) get() string {
@@ -66,20 +69,65 @@ func (ht helpTemplate) formatted(prefixSpaces int, wrapLength int, wrapIndent in
 func DisplayHelp(opts *Options, registry Registry, help string) error {
 	if help == "" {
 		DisplayRootHelp(opts, registry)
-	} else {
-		entry := registry.EntryFor(help)
-		if entry == nil {
-			opts.Printf("%s is not a valid command. Valid commands:\n", help)
-			DisplayRootHelp(opts, registry)
+		return nil
+	}
+
+	matches := registry.Matches(help)
+	switch len(matches) {
+	case 0:
+		DisplayCommandError(opts, registry, CommandNotFoundError{
+			Name:        help,
+			Suggestions: Suggest(help, registry.names()),
+		})
+	case 1:
+		return DisplayEntryHelp(opts, matches[0])
+	default:
+		DisplayCommandError(opts, registry, AmbiguousCommandError{
+			Name:    help,
+			Matches: matches,
+		})
+	}
+	return nil
+}
+
+// Displays a CommandNotFoundError or AmbiguousCommandError returned from Registry.Resolve or Registry.Capture
+// along with the commands the user may have meant. Other errors are displayed as is.
+func DisplayCommandError(opts *Options, registry Registry, err error) {
+	switch e := err.(type) {
+	case AmbiguousCommandError:
+		opts.Printf("%s is ambiguous, it matches the commands:\n", e.Name)
+		entries := make([]Entry, len(e.Matches))
+		for i, entry := range e.Matches {
+			entries[i] = *entry
+		}
+		displayEntries(opts, entries)
+	case CommandNotFoundError:
+		if e.Name == "" {
+			opts.Printf("%s requires a command. Valid commands:\n", strings.Join(e.Path, " "))
+			DisplayRootHelp(opts, registry.subAt(e.Path))
+		} else if len(e.Suggestions) > 0 {
+			opts.Printf("%s is not a valid command. Did you mean %s?\n", e.Name, strings.Join(e.Suggestions, " or "))
 		} else {
-			return DisplayEntryHelp(opts, entry)
+			opts.Printf("%s is not a valid command. Valid commands:\n", e.Name)
+			DisplayRootHelp(opts, registry.subAt(e.Path))
 		}
+	default:
+		opts.Printf("%v\n", err)
 	}
-	return nil
 }
 
 func DisplayRootHelp(opts *Options, registry Registry) {
-	entries := registry.EntriesAll()
+	entries := make([]Entry, 0)
+	for _, entry := range registry.EntriesAll() {
+		if !entry.Hidden {
+			entries = append(entries, entry)
+		}
+	}
+	displayEntries(opts, entries)
+}
+
+// Displays the entries names and short help in aligned columns.
+func displayEntries(opts *Options, entries []Entry) {
 	maxLength := 0
 	for _, entry := range entries {
 		if len(entry.Name) > maxLength {
@@ -125,6 +173,10 @@ func DisplayEntryHelp(opts *Options, entry *Entry) error {
 		opts.Printf("  %s\n", entry.HelpShort)
 	}
 
+	if entry.Command != nil {
+		opts.Printf("  Usage: %s\n", entryUsage(opts, entry))
+	}
+
 	helpTpl := helpTemplate{
 		Options: opts,
 	}
@@ -151,7 +203,7 @@ func DisplayEntryHelp(opts *Options, entry *Entry) error {
 		avoidMore[typ] = struct{}{}
 
 		for _, prop := range inst.PropertyList {
-			if prop.Help == "-" || (prop.HidePrompt && prop.Arg == "") {
+			if prop.Help == "-" || (prop.HidePrompt && !prop.CanFromArgs()) {
 				continue
 			}
 
@@ -186,6 +238,14 @@ func DisplayEntryHelp(opts *Options, entry *Entry) error {
 
 			helpTpl.ArgPrefix = argPrefix
 			helpTpl.Arg = strings.ToLower(arg)
+			helpTpl.NegatedArg = ""
+			if prop.IsBool() && opts.ArgNegatePrefix != "" {
+				helpTpl.NegatedArg = strings.ToLower(argPrefix + opts.ArgNegatePrefix + prop.Arg)
+			}
+			helpTpl.PathEnv = ""
+			if (prop.EnvAuto || opts.EnvAuto) && prop.CanLoad() {
+				helpTpl.PathEnv = opts.PathEnv(arg, opts.ArgPrefix)
+			}
 			helpTpl.Prop = *prop
 
 			opts.Printf("%s%s\n", strings.Repeat(" ", depth*2), prop.Name)
@@ -218,3 +278,47 @@ func DisplayEntryHelp(opts *Options, entry *Entry) error {
 
 	return err
 }
+
+// Returns the usage synopsis of the entry, listing its positional arguments in order.
+// Required arguments are wrapped in <> and optional arguments in [].
+// ex: copy [options] <source> [targets...]
+func entryUsage(opts *Options, entry *Entry) string {
+	usage := entry.Name
+	if opts.ProgramName != "" {
+		usage = opts.ProgramName + " " + usage
+	}
+	usage += " [options]"
+
+	inst := GetInstance(reflect.New(concreteType(reflect.TypeOf(entry.Command))))
+	positionals := make([]*Property, 0)
+	var rest *Property
+	for _, prop := range inst.PropertyList {
+		if prop.PosRest {
+			rest = prop
+		} else if prop.Pos > 0 {
+			positionals = append(positionals, prop)
+		}
+	}
+	sort.SliceStable(positionals, func(i, j int) bool {
+		return positionals[i].Pos < positionals[j].Pos
+	})
+
+	for _, prop := range positionals {
+		name := strings.ToLower(prop.Name)
+		if prop.IsOptional() || prop.Default != "" {
+			usage += " [" + name + "]"
+		} else {
+			usage += " <" + name + ">"
+		}
+	}
+	if rest != nil {
+		name := strings.ToLower(rest.Name)
+		if rest.Min != nil && *rest.Min > 0 {
+			usage += " <" + name + "...>"
+		} else {
+			usage += " [" + name + "...]"
+		}
+	}
+
+	return usage
+}
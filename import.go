@@ -0,0 +1,239 @@
+package cmdgo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Imports TOML data into the target. Fields are matched with the toml tag or their name.
+func ImportToml(data []byte, target any) error {
+	return toml.Unmarshal(data, target)
+}
+
+// Imports INI data into the target. Keys before any section are matched to fields of the target
+// and keys in a section are matched to fields of the struct field named by the section. A section
+// can have dots to refer to deeper structs, ex: [server.tls]. Fields are matched with the ini tag
+// or their name, ignoring case and non-alphanumeric characters. Keys with no matching field are ignored.
+func ImportIni(data []byte, target any) error {
+	value := reflect.ValueOf(target)
+	section := []string{}
+	lineNumber := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return fmt.Errorf("ini line %d: %w", lineNumber, ErrInvalidFormat)
+			}
+			section = strings.Split(strings.TrimSpace(line[1:len(line)-1]), ".")
+			continue
+		}
+
+		separator := strings.IndexAny(line, "=:")
+		if separator == -1 {
+			return fmt.Errorf("ini line %d: %w", lineNumber, ErrInvalidFormat)
+		}
+		key := strings.TrimSpace(line[:separator])
+		text := unquoteImported(strings.TrimSpace(line[separator+1:]))
+
+		path := append(append([]string{}, section...), key)
+		_, err := setTaggedField(value, path, "ini", text)
+		if err != nil {
+			return fmt.Errorf("ini line %d: %w", lineNumber, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Imports dotenv data into the target. Each KEY=value line is applied to every field of the target,
+// including fields of nested structs, which has KEY in its env tag. Lines can start with "export ",
+// values can be quoted, and lines starting with # are comments.
+func ImportEnvFile(data []byte, target any) error {
+	value := reflect.ValueOf(target)
+	lineNumber := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		separator := strings.IndexByte(line, '=')
+		if separator == -1 {
+			return fmt.Errorf("env line %d: %w", lineNumber, ErrInvalidFormat)
+		}
+		key := strings.TrimSpace(line[:separator])
+		text := unquoteImported(strings.TrimSpace(line[separator+1:]))
+
+		_, err := setEnvField(value, key, text, map[reflect.Type]struct{}{})
+		if err != nil {
+			return fmt.Errorf("env line %d: %w", lineNumber, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Removes the quotes surrounding an imported value. Double quoted values can have escapes like \n and \".
+func unquoteImported(text string) string {
+	if len(text) < 2 {
+		return text
+	}
+	switch {
+	case text[0] == '"' && text[len(text)-1] == '"':
+		if unquoted, err := strconv.Unquote(text); err == nil {
+			return unquoted
+		}
+		return text[1 : len(text)-1]
+	case text[0] == '\'' && text[len(text)-1] == '\'':
+		return text[1 : len(text)-1]
+	}
+	return text
+}
+
+// Sets the field at the end of the path of field names to the text. Fields are matched with the given tag
+// or their name and nil pointers along the path are only allocated if the field is found.
+// Returns whether the field was found.
+func setTaggedField(value reflect.Value, path []string, tag string, text string) (bool, error) {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			created := reflect.New(value.Type().Elem())
+			set, err := setTaggedField(created, path, tag, text)
+			if set && err == nil {
+				value.Set(created)
+			}
+			return set, err
+		}
+		if len(path) == 0 {
+			return true, setImportedText(value, text)
+		}
+		return setTaggedField(value.Elem(), path, tag, text)
+	}
+
+	if len(path) == 0 {
+		return true, setImportedText(value, text)
+	}
+	if value.Kind() != reflect.Struct {
+		return false, nil
+	}
+
+	normal := Normalize(path[0])
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		tagged := false
+		if tagName, ok := field.Tag.Lookup(tag); ok {
+			tagName, _, _ = strings.Cut(tagName, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+				tagged = true
+			}
+		}
+		if field.Anonymous && !tagged {
+			set, err := setTaggedField(value.Field(i), path, tag, text)
+			if set || err != nil {
+				return set, err
+			}
+			continue
+		}
+		if Normalize(name) == normal {
+			return setTaggedField(value.Field(i), path[1:], tag, text)
+		}
+	}
+
+	return false, nil
+}
+
+// Sets every field which has the key in its env tag to the text, searching nested structs and
+// allocating nil pointers to structs only if a field is found. Returns whether a field was found.
+func setEnvField(value reflect.Value, key string, text string, avoidTypes map[reflect.Type]struct{}) (bool, error) {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			created := reflect.New(value.Type().Elem())
+			set, err := setEnvField(created.Elem(), key, text, avoidTypes)
+			if set && err == nil {
+				value.Set(created)
+			}
+			return set, err
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return false, nil
+	}
+
+	typ := value.Type()
+	if _, exists := avoidTypes[typ]; exists {
+		return false, nil
+	}
+	avoidTypes[typ] = struct{}{}
+	defer delete(avoidTypes, typ)
+
+	found := false
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if env, ok := field.Tag.Lookup("env"); ok {
+			for _, name := range strings.Split(env, ",") {
+				if name == key {
+					err := setImportedText(value.Field(i), text)
+					if err != nil {
+						return found, err
+					}
+					found = true
+					break
+				}
+			}
+		}
+		if concreteType(field.Type).Kind() == reflect.Struct {
+			set, err := setEnvField(value.Field(i), key, text, avoidTypes)
+			if err != nil {
+				return found, err
+			}
+			found = found || set
+		}
+	}
+
+	return found, nil
+}
+
+// Sets the value to the imported text, using encoding.TextUnmarshaler if the value implements it.
+func setImportedText(value reflect.Value, text string) error {
+	if value.CanAddr() {
+		if unmarshaler, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return unmarshaler.UnmarshalText([]byte(text))
+		}
+	}
+	if value.Kind() == reflect.Pointer && !value.IsNil() {
+		if unmarshaler, ok := value.Interface().(encoding.TextUnmarshaler); ok {
+			return unmarshaler.UnmarshalText([]byte(text))
+		}
+	}
+	return SetString(value, text)
+}
@@ -0,0 +1,187 @@
+package cmdgo
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+type ImportServer struct {
+	Host string `toml:"hostname" ini:"hostname" env:"SERVER_HOST"`
+	Port int    `env:"SERVER_PORT,PORT"`
+}
+
+type ImportCommand struct {
+	Name    string `env:"APP_NAME"`
+	Debug   bool   `toml:"debug_mode" ini:"debug_mode" env:"APP_DEBUG"`
+	Tags    []string
+	Ignored string `toml:"-" ini:"-" env:"-"`
+	Server  ImportServer
+	Backup  *ImportServer
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name          string
+		importer      string
+		data          string
+		expected      ImportCommand
+		expectedError error
+	}{
+		{
+			name:     "toml",
+			importer: "toml",
+			data: `
+name = "app"
+debug_mode = true
+tags = ["a", "b"]
+ignored = "x"
+
+[server]
+hostname = "localhost"
+port = 8080
+`,
+			expected: ImportCommand{
+				Name:  "app",
+				Debug: true,
+				Tags:  []string{"a", "b"},
+				Server: ImportServer{
+					Host: "localhost",
+					Port: 8080,
+				},
+			},
+		},
+		{
+			name:     "ini",
+			importer: "ini",
+			data: `
+; comment
+name = "app"
+debug_mode: true
+tags = a,b
+ignored = x
+unknown = y
+
+[Server]
+hostname = localhost
+port = 8080
+
+[backup]
+port = 9090
+`,
+			expected: ImportCommand{
+				Name:  "app",
+				Debug: true,
+				Tags:  []string{"a", "b"},
+				Server: ImportServer{
+					Host: "localhost",
+					Port: 8080,
+				},
+				Backup: &ImportServer{
+					Port: 9090,
+				},
+			},
+		},
+		{
+			name:          "ini invalid",
+			importer:      "ini",
+			data:          "[server\nport = 1",
+			expectedError: ErrInvalidFormat,
+		},
+		{
+			name:     "env-file",
+			importer: "env-file",
+			data: `
+# comment
+APP_NAME='app'
+export APP_DEBUG=true
+SERVER_HOST="local\thost"
+PORT=8080
+UNKNOWN=x
+`,
+			expected: ImportCommand{
+				Name:  "app",
+				Debug: true,
+				Server: ImportServer{
+					Host: "local\thost",
+					Port: 8080,
+				},
+				Backup: &ImportServer{
+					Host: "local\thost",
+					Port: 8080,
+				},
+			},
+		},
+		{
+			name:          "env-file invalid",
+			importer:      "env-file",
+			data:          "APP_NAME",
+			expectedError: ErrInvalidFormat,
+		},
+	}
+
+	for _, test := range tests {
+		actual := ImportCommand{}
+		err := CaptureImports[test.importer]([]byte(test.data), &actual)
+		if err != nil {
+			if test.expectedError == nil {
+				t.Errorf("Test [%s] failed with error %v", test.name, err)
+			} else if !errors.Is(err, test.expectedError) {
+				t.Errorf("Test [%s] expected error %s but got %s", test.name, test.expectedError.Error(), err.Error())
+			}
+		} else if test.expectedError != nil {
+			t.Errorf("Test [%s] expected error %s", test.name, test.expectedError.Error())
+		} else if !equalsJson(actual, test.expected) {
+			t.Errorf("Test [%s] expected %s but got %s", test.name, toJson(test.expected), toJson(actual))
+		}
+	}
+}
+
+func TestImportArg(t *testing.T) {
+	path := t.TempDir() + "/config.toml"
+	err := os.WriteFile(path, []byte("message = \"from toml\""), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := CreateRegistry([]Entry{
+		{Name: "simple", Command: SimpleCommand{}},
+	})
+
+	opts := NewOptions().WithArgs([]string{"simple", "--toml", path})
+	cmd, err := registry.Capture(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if simple := cmd.(*SimpleCommand); simple.Message != "from toml" {
+		t.Errorf("Expected message from toml but got %s", simple.Message)
+	}
+}
+
+func TestImportArgAddedLater(t *testing.T) {
+	path := t.TempDir() + "/config.txt"
+	err := os.WriteFile(path, []byte("from text"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := CreateRegistry([]Entry{
+		{Name: "simple", Command: SimpleCommand{}},
+	})
+
+	opts := NewOptions().WithArgs([]string{"simple", "--text", path})
+
+	CaptureImports["text"] = func(data []byte, target any) error {
+		target.(*SimpleCommand).Message = string(data)
+		return nil
+	}
+	defer delete(CaptureImports, "text")
+
+	cmd, err := registry.Capture(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if simple := cmd.(*SimpleCommand); simple.Message != "from text" {
+		t.Errorf("Expected message from text but got %s", simple.Message)
+	}
+}
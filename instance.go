@@ -2,6 +2,9 @@ package cmdgo
 
 import (
 	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // An instance of a struct and all the properties on it.
@@ -9,6 +12,9 @@ type Instance struct {
 	Value        reflect.Value
 	PropertyMap  map[string]*Property
 	PropertyList []*Property
+
+	// If this is the instance of a nested value, which does not take positional or short arguments.
+	nested bool
 }
 
 // Creates an instance given a value.
@@ -30,9 +36,11 @@ func GetInstance(value any) Instance {
 // Creates an instance that is appropriate for the given property.
 func GetSubInstance(value any, prop Property) Instance {
 	instance := GetInstance(value)
+	instance.nested = true
 
 	if concreteKind(instance.Value) != reflect.Struct {
 		instance.AddProperty(&Property{
+			element:     true,
 			Value:       instance.Value,
 			Type:        instance.Value.Type(),
 			Name:        prop.Name,
@@ -56,37 +64,138 @@ func (inst *Instance) Capture(opts *Options) error {
 		}
 	}
 
-	for _, property := range inst.PropertyList {
-		err := property.Load(opts)
+	positionals := instancePositionals{}
+	release := func() {}
+
+	if opts.args == nil {
+		opts.valuePath = ""
+		opts.argOrigins = getArgOrigins(opts.Args, opts.ArgsOriginal)
+
+		err := inst.expandShortArgs(opts)
 		if err != nil {
 			return err
 		}
 
-		err = property.FromArgs(opts)
+		positionals, err = inst.takePositionals(opts)
 		if err != nil {
 			return err
 		}
 
-		err = property.Prompt(opts)
+		opts.args = newArgIndex(opts.Args, opts.ArgPrefix)
+		opts.args.origins = opts.argOrigins
+		opts.argOrigins = nil
+		release = func() {
+			opts.Args = append(opts.args.remaining(), positionals.unused()...)
+			opts.args = nil
+		}
+	}
+
+	err := inst.captureProperties(opts, positionals)
+	release()
+	if err != nil {
+		return err
+	}
+
+	if validate, ok := valueRaw.(Validator); ok {
+		err := validate.Validate(opts)
 		if err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
+
+// Loads, prompts, and validates each property of the instance in order. If the user enters the BackPrompt
+// capturing goes back to the last property prompted, or returns ErrBack if no property in a nested instance
+// was prompted so the value it's in can go back.
+func (inst *Instance) captureProperties(opts *Options, positionals instancePositionals) error {
+	instance := opts.instance
+	opts.instance = inst
+	defer func() {
+		opts.instance = instance
+	}()
+
+	// The indices of the properties which prompted in the order they were captured.
+	prompted := []int{}
+	// The number of properties which have been loaded and populated from the arguments.
+	visited := 0
 
-		err = property.Validate(opts)
+	for i := 0; i < len(inst.PropertyList); i++ {
+		err := opts.Context().Err()
 		if err != nil {
 			return err
 		}
 
-		if dynamic, ok := valueRaw.(Dynamic); ok {
-			err = dynamic.Update(opts, property, inst)
-			if err != nil {
+		prompts := opts.prompts
+		err = inst.captureProperty(opts, inst.PropertyList[i], positionals, i < visited)
+		if i >= visited {
+			visited = i + 1
+		}
+
+		if err == ErrBack {
+			if len(prompted) > 0 {
+				i = prompted[len(prompted)-1] - 1
+				prompted = prompted[:len(prompted)-1]
+				continue
+			}
+			if inst.nested {
 				return err
 			}
+			i--
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if opts.prompts > prompts {
+			prompted = append(prompted, i)
 		}
 	}
 
-	if validate, ok := valueRaw.(Validator); ok {
-		err := validate.Validate(opts)
+	return nil
+}
+
+// Loads, populates from the arguments, prompts, and validates the property and then updates a dynamic value.
+// When a property is revisited after going back it's only prompted, which for complex and custom values is
+// done while populating them.
+func (inst *Instance) captureProperty(opts *Options, property *Property, positionals instancePositionals, revisit bool) error {
+	var err error
+
+	switch {
+	case !revisit:
+		err = property.Load(opts)
+		if err != nil {
+			return err
+		}
+
+		if values := positionals.forProperty(property); len(values) > 0 {
+			err = property.FromPositionals(opts, values)
+		} else {
+			err = property.FromArgs(opts)
+		}
+	case property.getPromptValue(opts) != nil:
+		_, err = property.promptValue(opts)
+	case !property.IsSimple():
+		err = property.FromArgs(opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = property.Prompt(opts)
+	if err != nil {
+		return err
+	}
+
+	err = property.Validate(opts)
+	if err != nil {
+		return err
+	}
+
+	if dynamic, ok := inst.Value.Interface().(Dynamic); ok {
+		err = dynamic.Update(opts, property, inst)
 		if err != nil {
 			return err
 		}
@@ -95,6 +204,255 @@ func (inst *Instance) Capture(opts *Options) error {
 	return nil
 }
 
+// Expands the short arguments in the options into their full argument names. A short argument is the
+// short prefix followed by one or more short names, where the last short name can be followed by its value.
+// An argument with a short name that is not known is left as is. ex: -v, -abc, -ofile, -o=file, -o file
+func (inst Instance) expandShortArgs(opts *Options) error {
+	if inst.nested || opts.ShortPrefix == "" {
+		return nil
+	}
+
+	infos, err := getArgInfos(opts, inst)
+	if err != nil {
+		return err
+	}
+
+	shorts := make(map[rune]argInfo)
+	for _, info := range infos {
+		if info.Prop.Short == "" {
+			continue
+		}
+		short, _ := utf8.DecodeRuneInString(info.Prop.Short)
+		if _, exists := shorts[short]; !exists {
+			shorts[short] = info
+		}
+	}
+	if len(shorts) == 0 {
+		return nil
+	}
+
+	expand := func(arg string) []string {
+		if !strings.HasPrefix(arg, opts.ShortPrefix) || IsArgName(arg, opts.ArgPrefix) {
+			return nil
+		}
+		names := []rune(arg[len(opts.ShortPrefix):])
+		expanded := make([]string, 0, len(names))
+		for i, name := range names {
+			info, exists := shorts[name]
+			if !exists {
+				return nil
+			}
+			rest := string(names[i+1:])
+			if strings.HasPrefix(rest, "=") {
+				return append(expanded, info.Arg+rest)
+			}
+			if !info.Prop.IsFlag() && rest != "" {
+				return append(expanded, info.Arg+"="+rest)
+			}
+			expanded = append(expanded, info.Arg)
+		}
+		return expanded
+	}
+
+	args := make([]string, 0, len(opts.Args))
+	origins := make([]int, 0, len(opts.Args))
+	for i, arg := range opts.Args {
+		if arg == ArgTerminator {
+			args = append(args, opts.Args[i:]...)
+			origins = append(origins, opts.argOriginsAt(i, len(opts.Args)-i)...)
+			break
+		}
+		expanded := expand(arg)
+		if len(expanded) == 0 {
+			expanded = []string{arg}
+		}
+		args = append(args, expanded...)
+		for range expanded {
+			origins = append(origins, opts.argOriginsAt(i, 1)...)
+		}
+	}
+
+	opts.Args = args
+	if opts.argOrigins != nil {
+		opts.argOrigins = origins
+	}
+
+	return nil
+}
+
+// Returns the positions in ArgsOriginal of count arguments in Args starting at the given position, or -1 for each
+// if the positions are not being tracked.
+func (opts *Options) argOriginsAt(position int, count int) []int {
+	origins := make([]int, count)
+	for i := range origins {
+		origins[i] = -1
+		if position+i < len(opts.argOrigins) {
+			origins[i] = opts.argOrigins[position+i]
+		}
+	}
+	return origins
+}
+
+// The positional arguments taken from the options for an instance.
+type instancePositionals struct {
+	values []string
+	last   int
+	rest   bool
+}
+
+// Returns the positional values for the given property.
+func (ip instancePositionals) forProperty(prop *Property) []string {
+	if prop.PosRest && ip.last < len(ip.values) {
+		return ip.values[ip.last:]
+	}
+	if prop.Pos > 0 && prop.Pos <= len(ip.values) {
+		return ip.values[prop.Pos-1 : prop.Pos]
+	}
+	return nil
+}
+
+// Returns the positional values which no property is populated by.
+func (ip instancePositionals) unused() []string {
+	if ip.rest || ip.last >= len(ip.values) {
+		return nil
+	}
+	return ip.values[ip.last:]
+}
+
+// Removes the positional arguments from the options args if this instance has positional properties.
+// A positional argument is any argument that is not an argument name or the value that follows it.
+// The value of a bool argument only follows it if it's a valid bool. Short arguments which were not
+// expanded are not positional, and all arguments after the ArgTerminator are positional.
+func (inst Instance) takePositionals(opts *Options) (instancePositionals, error) {
+	positionals := instancePositionals{}
+
+	for _, prop := range inst.PropertyList {
+		if prop.Pos > positionals.last {
+			positionals.last = prop.Pos
+		}
+		if prop.PosRest {
+			positionals.rest = true
+		}
+	}
+
+	if inst.nested || (positionals.last == 0 && !positionals.rest) {
+		return positionals, nil
+	}
+
+	infos, err := getArgInfos(opts, inst)
+	if err != nil {
+		return positionals, err
+	}
+
+	bools := make(map[string]bool)
+	counts := make(map[string]bool)
+	for _, info := range infos {
+		switch {
+		case info.Prop.Count:
+			counts[Normalize(info.Arg)] = true
+		case info.Prop.IsBool():
+			bools[Normalize(info.Arg)] = true
+			if opts.ArgNegatePrefix != "" && len(info.Arg) >= len(info.Prop.Arg) {
+				negated := info.Arg[:len(info.Arg)-len(info.Prop.Arg)] + opts.ArgNegatePrefix + info.Prop.Arg
+				bools[Normalize(negated)] = true
+			}
+		}
+	}
+
+	args := make([]string, 0, len(opts.Args))
+	origins := make([]int, 0, len(opts.Args))
+	keep := func(i int) {
+		args = append(args, opts.Args[i])
+		origins = append(origins, opts.argOriginsAt(i, 1)...)
+	}
+	for i := 0; i < len(opts.Args); i++ {
+		arg := opts.Args[i]
+		if arg == ArgTerminator {
+			positionals.values = append(positionals.values, opts.Args[i+1:]...)
+			break
+		}
+		if !IsArgName(arg, opts.ArgPrefix) {
+			if opts.ShortPrefix != "" && arg != opts.ShortPrefix && IsArgName(arg, opts.ShortPrefix) {
+				keep(i)
+			} else {
+				positionals.values = append(positionals.values, arg)
+			}
+			continue
+		}
+		keep(i)
+		name, _, hasValue := splitArgValue(arg)
+		if counts[Normalize(name)] {
+			continue
+		}
+		if !hasValue && i+1 < len(opts.Args) && !IsArgName(opts.Args[i+1], opts.ArgPrefix) && opts.Args[i+1] != ArgTerminator {
+			if bools[Normalize(name)] {
+				if _, err := strconv.ParseBool(opts.Args[i+1]); err != nil {
+					continue
+				}
+			}
+			i++
+			keep(i)
+		}
+	}
+
+	opts.Args = args
+	if opts.argOrigins != nil {
+		opts.argOrigins = origins
+	}
+
+	return positionals, nil
+}
+
+// Returns an UnknownArgsError if Options.StrictArgs is true and any arguments remain in
+// the options after capture. Each argument is given suggestions from the arguments of this instance.
+func (inst Instance) CheckArgs(opts *Options) error {
+	if !opts.StrictArgs {
+		return nil
+	}
+
+	args := make([]string, 0, len(opts.Args))
+	for _, arg := range opts.Args {
+		if arg != ArgTerminator {
+			args = append(args, arg)
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	infos, err := getArgInfos(opts, inst)
+	if err != nil {
+		return err
+	}
+
+	known := []string{opts.ArgPrefix + "help", opts.ArgPrefix + "interactive"}
+	if explain := opts.explainArg(infos); explain != "" {
+		known = append(known, explain)
+	}
+	for _, importer := range opts.importers() {
+		known = append(known, opts.ArgPrefix+importer.Name)
+	}
+	for _, exporter := range opts.exporters() {
+		known = append(known, opts.ArgPrefix+opts.ArgExportPrefix+exporter.Name)
+	}
+	for _, info := range infos {
+		known = append(known, info.Arg)
+	}
+
+	unknown := UnknownArgsError{
+		Args: make([]UnknownArg, len(args)),
+	}
+	for i, arg := range args {
+		unknown.Args[i].Arg = arg
+		if IsArgName(arg, opts.ArgPrefix) {
+			name, _, _ := splitArgValue(arg)
+			unknown.Args[i].Suggestions = Suggest(name, known)
+		}
+	}
+
+	return unknown
+}
+
 // Returns if the value in this instance has all default values.
 func (inst Instance) IsDefault() bool {
 	for _, prop := range inst.PropertyList {
@@ -46,3 +46,14 @@ type PromptValue interface {
 type HasChoices interface {
 	GetChoices(opts *Options, prop *Property) PromptChoices
 }
+
+// A value which has completion candidates for its argument value that are only known at runtime.
+type HasCompletions interface {
+	GetCompletions(opts *Options, prop *Property, partial string) []string
+}
+
+// A value with custom arg handling logic which can also return the arguments which recreate it.
+// The arguments should use opts.ArgPrefix and prop.Arg for their names. See MarshalArgs.
+type ArgMarshaler interface {
+	MarshalArgs(opts *Options, prop *Property) ([]string, error)
+}
@@ -2,17 +2,215 @@ package cmdgo
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 // An error returned from Unmarshal if nil or a non-pointer is passed to Unmarshal.
 var ErrInvalidUnmarshalError = errors.New("non-pointer passed to Unmarshal")
 
+// An error returned from MarshalArgs if nil is passed to MarshalArgs.
+var ErrInvalidMarshalError = errors.New("nil passed to MarshalArgs")
+
 // Unmarshal parses the arguments and prompts in opts and stores the result in the value pointed to by v. If v is nil or not a pointer, Unmarshal returns an InvalidUnmarshalError.
+// If opts.StrictArgs is true and arguments remain after parsing, Unmarshal returns an UnknownArgsError.
+// If opts.ArgFiles is true then @path arguments are expanded before parsing.
 func Unmarshal(opts *Options, v any) error {
 	if v == nil || reflect.ValueOf(v).Kind() != reflect.Pointer {
 		return ErrInvalidUnmarshalError
 	}
+	if opts.ArgFiles {
+		err := opts.ExpandArgFiles()
+		if err != nil {
+			return err
+		}
+	}
 	inst := GetInstance(v)
-	return inst.Capture(opts)
+	err := inst.Capture(opts)
+	if err != nil {
+		return err
+	}
+	return inst.CheckArgs(opts)
+}
+
+// MarshalArgs returns the arguments which recreate v when given to Unmarshal with the same options.
+// Argument names are generated with opts.ArgPrefix and the arg templates the same way Unmarshal reads them,
+// ex: --profile-name x --movies-1-title y. Properties with zero values are left out unless their default
+// tag would give them another value, except slice and array elements and map keys and values which are given
+// even when empty, ex: --tags= to keep the positions of the others. A property with custom arg handling (ArgValue) must implement
+// ArgMarshaler or an ErrUnsupportedType error is returned.
+func MarshalArgs(opts *Options, v any) ([]string, error) {
+	if v == nil {
+		return nil, ErrInvalidMarshalError
+	}
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer {
+		value = pointerOf(value)
+	}
+
+	argPrefix := opts.ArgPrefix
+	defer func() {
+		opts.ArgPrefix = argPrefix
+	}()
+
+	return marshalInstance(opts, GetInstance(value), argPrefix)
+}
+
+func marshalInstance(opts *Options, inst Instance, argPrefix string) ([]string, error) {
+	args := make([]string, 0)
+	for _, prop := range inst.PropertyList {
+		propArgs, err := marshalProperty(opts, prop, argPrefix)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, propArgs...)
+	}
+	return args, nil
+}
+
+func marshalProperty(opts *Options, prop *Property, argPrefix string) ([]string, error) {
+	if !prop.CanFromArgs() {
+		return nil, nil
+	}
+
+	candidate := prop.Value
+	if candidate.CanAddr() {
+		candidate = candidate.Addr()
+	}
+	if marshaler, ok := candidate.Interface().(ArgMarshaler); ok {
+		opts.ArgPrefix = argPrefix
+		return marshaler.MarshalArgs(opts, prop)
+	}
+	if prop.getArgValue(opts) != nil || prop.getPromptValue(opts) != nil {
+		if prop.IsDefault() {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", prop.Name, ErrUnsupportedType)
+	}
+	if prop.IsOptional() && prop.IsNil() {
+		return nil, nil
+	}
+
+	switch {
+	case prop.IsSimple():
+		return marshalSimple(opts, prop, argPrefix)
+	case prop.IsStruct():
+		structTemplate := prop.getArgTemplate(argPrefix, reflect.Struct, opts.ArgStructTemplate)
+		prefix, err := structTemplate.get()
+		if err != nil {
+			return nil, err
+		}
+		return marshalValue(opts, *prop, concreteValue(prop.Value), prefix)
+	case prop.IsSlice(), prop.IsArray():
+		list := concreteValue(prop.Value)
+		listTemplate := opts.ArgSliceTemplate
+		if prop.IsArray() {
+			listTemplate = opts.ArgArrayTemplate
+		}
+		elementTemplate := prop.getArgTemplate(argPrefix, concreteType(list.Type().Elem()).Kind(), listTemplate)
+		args := make([]string, 0)
+		for i := 0; i < list.Len(); i++ {
+			elementTemplate.Index = i + opts.ArgStartIndex
+			elementPrefix, err := elementTemplate.get()
+			if err != nil {
+				return nil, err
+			}
+			elementArgs, err := marshalValue(opts, *prop, list.Index(i), elementPrefix)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, elementArgs...)
+		}
+		return args, nil
+	case prop.IsMap():
+		mp := concreteValue(prop.Value)
+		keyTemplate := prop.getArgTemplate(argPrefix, concreteType(mp.Type().Key()).Kind(), opts.ArgMapKeyTemplate)
+		valueTemplate := prop.getArgTemplate(argPrefix, concreteType(mp.Type().Elem()).Kind(), opts.ArgMapValueTemplate)
+		keys := mp.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		args := make([]string, 0)
+		for i, key := range keys {
+			keyTemplate.Index = i + opts.ArgStartIndex
+			valueTemplate.Index = i + opts.ArgStartIndex
+			keyPrefix, err := keyTemplate.get()
+			if err != nil {
+				return nil, err
+			}
+			valuePrefix, err := valueTemplate.get()
+			if err != nil {
+				return nil, err
+			}
+			keyArgs, err := marshalValue(opts, *prop, pointerOf(key).Elem(), keyPrefix)
+			if err != nil {
+				return nil, err
+			}
+			valueArgs, err := marshalValue(opts, *prop, pointerOf(mp.MapIndex(key)).Elem(), valuePrefix)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, keyArgs...)
+			args = append(args, valueArgs...)
+		}
+		return args, nil
+	}
+
+	return nil, nil
+}
+
+// Returns the arguments of a struct, slice element, or map key or value the way captureValue reads them.
+func marshalValue(opts *Options, prop Property, value reflect.Value, argPrefix string) ([]string, error) {
+	return marshalInstance(opts, GetSubInstance(value, prop), argPrefix)
+}
+
+func marshalSimple(opts *Options, prop *Property, argPrefix string) ([]string, error) {
+	name := strings.ToLower(argPrefix + prop.Arg)
+	concrete := concreteValue(prop.Value)
+
+	// Slice elements and map keys and values are kept even when they're empty so the other elements keep their place.
+	if prop.IsDefault() && prop.Default == "" && !prop.element {
+		return nil, nil
+	}
+
+	if concrete.Kind() == reflect.Bool && !prop.Count {
+		if concrete.Bool() {
+			return []string{name}, nil
+		}
+		return []string{name + "=false"}, nil
+	}
+
+	text := fmt.Sprint(concrete.Interface())
+	if prop.isBytes() {
+		text = string(concrete.Bytes())
+	}
+	if prop.FromFile && strings.HasPrefix(text, "@") {
+		text = "@" + text
+	}
+	if prop.Count {
+		return []string{name + "=" + text}, nil
+	}
+	if choices := prop.GetPromptChoices(opts); choices != nil && choices.HasChoices() {
+		for _, key := range sortedKeys(choices) {
+			if choice := choices[key]; choice.Value == text {
+				text = key
+				if choice.Text != "" {
+					text = choice.Text
+				}
+				break
+			}
+		}
+	}
+	if text == "" {
+		if prop.element {
+			return []string{name + "="}, nil
+		}
+		return nil, nil
+	}
+	if IsArgName(text, opts.ArgPrefix) || text == ArgTerminator {
+		return []string{name + "=" + text}, nil
+	}
+	return []string{name, text}, nil
 }
@@ -33,3 +33,116 @@ func TestUnmarshall(t *testing.T) {
 
 	}
 }
+
+type MarshalMovie struct {
+	Title  string
+	Rating float64
+}
+
+type MarshalProfile struct {
+	Name    string
+	Private bool
+}
+
+type MarshalCommand struct {
+	Profile  MarshalProfile
+	Movies   []MarshalMovie
+	Tags     []string
+	Labels   map[string]int
+	Scores   [2]int
+	Level    string `options:"low:1,high:2"`
+	Verbose  int    `count:"true"`
+	Enabled  bool   `default:"true"`
+	Offset   *int
+	Define   string
+	Ignored  string `arg:"-"`
+	Optional *MarshalProfile
+}
+
+func TestMarshalArgs(t *testing.T) {
+	offset := 0
+	value := MarshalCommand{
+		Profile: MarshalProfile{Name: "x", Private: true},
+		Movies: []MarshalMovie{
+			{Title: "y", Rating: 4.5},
+			{Title: "z"},
+		},
+		Tags:    []string{"a", "b"},
+		Labels:  map[string]int{"b": 2, "a": 1},
+		Scores:  [2]int{0, 3},
+		Level:   "2",
+		Verbose: 3,
+		Enabled: false,
+		Offset:  &offset,
+		Define:  "--x=1",
+		Ignored: "ignored",
+	}
+
+	args, err := MarshalArgs(NewOptions(), value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"--profile-name", "x", "--profile-private",
+		"--movies-1-title", "y", "--movies-1-rating", "4.5", "--movies-2-title", "z",
+		"--tags", "a", "--tags", "b",
+		"--labels-key", "a", "--labels-value", "1", "--labels-key", "b", "--labels-value", "2",
+		"--scores-1", "0", "--scores-2", "3",
+		"--level", "high",
+		"--verbose=3",
+		"--enabled=false",
+		"--offset", "0",
+		"--define=--x=1",
+	}
+	if !equalsJson(args, expected) {
+		t.Errorf("Expected %s but got %s", toJson(expected), toJson(args))
+	}
+
+	actual := MarshalCommand{}
+	err = Unmarshal(NewOptions().WithArgs(args), &actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value.Ignored = ""
+	if !equalsJson(actual, value) {
+		t.Errorf("Expected %s but got %s", toJson(value), toJson(actual))
+	}
+}
+
+type MarshalEmptyCommand struct {
+	Items  []string
+	Counts []int
+	Labels map[string]string
+}
+
+func TestMarshalArgsEmptyElements(t *testing.T) {
+	value := MarshalEmptyCommand{
+		Items:  []string{"a", "", "c"},
+		Counts: []int{0, 2},
+		Labels: map[string]string{"": "x", "y": ""},
+	}
+
+	args, err := MarshalArgs(NewOptions(), value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"--items", "a", "--items=", "--items", "c",
+		"--counts", "0", "--counts", "2",
+		"--labels-key=", "--labels-value", "x", "--labels-key", "y", "--labels-value=",
+	}
+	if !equalsJson(args, expected) {
+		t.Errorf("Expected %s but got %s", toJson(expected), toJson(args))
+	}
+
+	actual := MarshalEmptyCommand{}
+	err = Unmarshal(NewOptions().WithArgs(args), &actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalsJson(actual, value) {
+		t.Errorf("Expected %s but got %s", toJson(value), toJson(actual))
+	}
+}
@@ -8,10 +8,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"strings"
 	"text/template"
+	"unicode"
 
 	"golang.org/x/term"
 )
@@ -22,6 +24,9 @@ var ErrQuit = errors.New("QUIT")
 // The error returned when the user requested to discard the current value being prompted for a complex type.
 var ErrDiscard = errors.New("DISCARD")
 
+// The error returned when the user requested to go back to the previous value prompted.
+var ErrBack = errors.New("BACK")
+
 // The error returned when no valid value could be gotten from prompt.
 var ErrNoPrompt = errors.New("NOPROMPT")
 
@@ -31,13 +36,26 @@ var ErrVerifyFailed = errors.New("NOVERIFY")
 // The error returned when the input did not match the specified regular expression.
 var ErrRegexFailed = errors.New("NOREGEX")
 
+// The error returned when the number of values selected is less than the min or more than the max.
+var ErrCountFailed = errors.New("NOCOUNT")
+
 // A dynamic set of variables that commands can have access to during unmarshal, capture, and execution.
 type Options struct {
 	// A general map of values that can be passed and shared between values being parsed, validated, and updated.
 	Values map[string]any
 
 	// A context can be passed and will be monitored by cmdgo. Defaults to context.Background()
+	// When it's done prompts return its error and capturing stops before the next property.
 	ctx context.Context
+	// The registry currently capturing or executing a command, if any.
+	registry *Registry
+	// The index of Args while an instance is being captured, Args is updated when capturing ends.
+	args *argIndex
+	// The position in ArgsOriginal of each of Args while an instance is being captured, or -1 if it's not in there.
+	argOrigins []int
+
+	// The name of the running program, used in completion scripts. Set with Cli().
+	ProgramName string
 
 	// The arguments to parse out
 	Args []string
@@ -45,6 +63,51 @@ type Options struct {
 	ArgsOriginal []string
 	// The prefix all argument names have, to differentiate argument names to values.
 	ArgPrefix string
+	// The prefix after ArgPrefix which sets a bool property to false, ex: --no-verbose. If empty bools can't be negated.
+	ArgNegatePrefix string
+	// The prefix short argument names have, ex: -v or -abc for bundled short arguments. Short argument
+	// names are given with the short tag and are only recognized when the argument does not have the ArgPrefix.
+	ShortPrefix string
+	// The suffix of an environment variable which has the path to a file with the value of the environment
+	// variable without the suffix, ex: DB_PASSWORD_FILE. If empty values are not read from files.
+	EnvFileSuffix string
+	// The prefix of environment variables named after property paths for properties with `env:"auto"`,
+	// ex: APP_ for APP_DB_HOST. See PathEnv.
+	EnvPrefix string
+	// If all properties are populated by environment variables named after their paths, as if they had `env:"auto"`.
+	EnvAuto bool
+	// If arguments which are not used to populate the command should return an UnknownArgsError.
+	StrictArgs bool
+	// The importers which can be given as arguments, ex: --json path/to/file.json
+	// Imported files are applied in the order they're given in the arguments. If nil the DefaultImporters are used,
+	// which are read from CaptureImports when they're needed so importers added to it later are included.
+	Importers []Importer
+	// The exporters which can be given as arguments with ArgExportPrefix, ex: --save-yaml path/to/file.yaml
+	// The captured command is exported after it's validated. If nil the DefaultExporters are used,
+	// which are read from CaptureExports when they're needed so exporters added to it later are included.
+	Exporters []Exporter
+	// The prefix of exporter argument names after ArgPrefix, ex: save- for --save-yaml
+	ArgExportPrefix string
+	// The argument name after ArgPrefix which prints the values of the captured command and their origins instead of
+	// returning it, ex: explain for --explain (the default). If empty or the command has a property with the argument it's not used.
+	ArgExplain string
+	// If config files named after the command should be discovered for entries without ConfigNames.
+	DiscoverConfig bool
+	// If all discovered config files should be imported, from the lowest to highest precedence directory.
+	// Otherwise only the config file in the highest precedence directory is imported.
+	ConfigMerge bool
+	// The directories searched for config files in order of precedence. If nil DefaultConfigDirs is used.
+	ConfigDirs []string
+	// The config files which were imported for the last captured command, in the order they were imported.
+	ConfigFiles []string
+	// If ${VAR} in discovered config files and imported files should be replaced with the value of the environment variable VAR.
+	ImportExpandEnv bool
+	// The origin of each value of the last captured command by property path. Origins are only recorded
+	// when this is not nil, and it's created when the ArgExplain argument is given.
+	Provenance Provenance
+	// If arguments in the form @path should be replaced with the arguments in the file at path before capture.
+	// The file is split into arguments the way a shell would and it can have @path arguments itself.
+	ArgFiles bool
 	// The number arrays and maps should start for argument parsing. The number will be in the argument name for arrays or for slices with complex values.
 	ArgStartIndex int
 	// The template used to generate the argument name/prefix for a struct property.
@@ -73,12 +136,24 @@ type Options struct {
 	QuitPrompt string
 	// The text that should discard the current slice element or map key/value being prompted.
 	DiscardPrompt string
+	// The text that should go back to the previous property, slice element, or map key/value prompted so it can be changed.
+	BackPrompt string
 	// If prompting should be disabled.
 	DisablePrompt bool
 	// If prompting should be done even if no input file was given.
 	ForcePrompt bool
 	// Prompts for a single value. Potentially multiple lines & hidden. If quit or discard prompts are given, the appropriate error is returned.
 	PromptOnce func(prompt string, options PromptOnceOptions) (string, error)
+	// Prompts for one of the choices of a value with choices and returns the text of the choice, or an empty string if none was picked.
+	// If one of the keywords in the options is typed instead (ex: the HelpPrompt or QuitPrompt) it's returned.
+	// By default a menu navigated with the arrow keys is displayed when the input is a terminal. If ErrSelectUnavailable
+	// is returned or this is nil the value is prompted for with PromptOnce.
+	PromptSelect func(prompt string, options PromptSelectOptions) (string, error)
+	// Prompts for any number of the choices of a slice of values with choices and returns the text of the selected choices.
+	// If one of the keywords in the options is typed instead (ex: the BackPrompt) it's returned as the only text.
+	// By default a menu where choices are checked with space is displayed when the input is a terminal. If ErrSelectUnavailable
+	// is returned or this is nil the values are prompted for with PromptOnce, separated by commas.
+	PromptSelectMany func(prompt string, options PromptSelectManyOptions) ([]string, error)
 	// Prompts the user to start a complex type (struct, slice, array, map) that they can avoid populating.
 	PromptStart func(prop Property) (bool, error)
 	// The valid options the user can enter which decides if they start prompting for a complex type. The input must match one of the keys (normalized) or prompting will be done repeatedly.
@@ -104,10 +179,28 @@ type Options struct {
 	// How many times the user should be prompted for a valid value.
 	RepromptOnInvalid int
 
+	// The prompt displayed for each line in Registry.Shell.
+	ShellPrompt string
+
+	// The file answers to prompts are saved to and loaded from, so they can be recalled with the up arrow key
+	// when prompting on a terminal. If empty answers are only recalled until the program exits.
+	PromptHistoryFile string
+	// The maximum number of answers recalled for each property, or zero for no maximum.
+	PromptHistorySize int
+
 	// Used for displaying and obtaining prompts.
 	in       *os.File
 	inReader *bufio.Reader
 	out      *os.File
+
+	// The path of the value being captured, used to record provenance.
+	valuePath string
+	// The instance whose properties are being captured, used to expand default tags.
+	instance *Instance
+	// The answers to prompts by history key, loaded from PromptHistoryFile when first needed.
+	history map[string][]string
+	// The number of values prompted for, used to know which properties can be gone back to.
+	prompts int
 }
 
 // A new options which by default has no arguments and does not support prompting.
@@ -137,6 +230,11 @@ func NewOptions() *Options {
 		Args:                make([]string, 0),
 		ArgsOriginal:        make([]string, 0),
 		ArgPrefix:           "--",
+		ArgNegatePrefix:     "no-",
+		EnvFileSuffix:       "_FILE",
+		ArgExportPrefix:     "save-",
+		ArgExplain:          "explain",
+		ShortPrefix:         "-",
 		ArgStartIndex:       1,
 		ArgStructTemplate:   newTemplate("{{ .Prefix }}{{ .Arg }}-"),
 		ArgSliceTemplate:    newTemplate("{{ .Prefix }}{{ .Arg }}{{ if not .IsSimple }}-{{ .Index }}-{{ end }}"),
@@ -200,18 +298,32 @@ func NewOptions() *Options {
 			{{ if .Prop.Default }}
 				- Has a default value of "{{ .Prop.Default }}".
 			{{ end }}
-			{{ if .Prop.Arg }}
+			{{ if .Prop.CanFromArgs }}
 				{{ if .Prop.IsSimple }}
-					- Can be specified with the argument {{ .Arg }}
+					- Can be specified with the argument {{ .Arg }}{{ if .Prop.Short }} or {{ .Options.ShortPrefix }}{{ .Prop.Short }}{{ end }}
+					{{ if .Prop.Count }}
+						- Can be repeated to count the number of times it's given
+					{{ else if .NegatedArg }}
+						- Can be set to false with the argument {{ .NegatedArg }}
+					{{ end }}
 				{{ else }}
 					- Has inner values that can be specified with arguments with the prefix {{ .Arg }}
 				{{ end }}
 			{{ end }}
-			{{ if .Prop.Env }}
+			{{ if .Prop.Pos }}
+				- Can be specified as positional argument {{ .Prop.Pos }}
+			{{ else if .Prop.PosRest }}
+				- Can be specified with the remaining positional arguments
+			{{ end }}
+			{{ if or .Prop.Env .PathEnv }}
 				- Can be populated by the environment variables:
 				{{- range .Prop.Env -}}
 					{{ " " }}{{ . }}
 				{{- end -}}
+				{{- if .PathEnv }} {{ .PathEnv }}{{ end -}}
+			{{ end }}
+			{{ if .Prop.FromFile }}
+				- Can be read from a file by giving @path as the value
 			{{ end }}
 		`),
 		HelpWrapWidth:   120,
@@ -219,6 +331,7 @@ func NewOptions() *Options {
 
 		QuitPrompt:         "quit!",
 		DiscardPrompt:      "discard!",
+		BackPrompt:         "back!",
 		DisablePrompt:      false,
 		ForcePrompt:        false,
 		PromptStartOptions: promptOptions,
@@ -269,9 +382,13 @@ func NewOptions() *Options {
 			return opts.Printf("%s\n", prop.PromptEnd)
 		},
 		PromptOnce: func(prompt string, options PromptOnceOptions) (string, error) {
-			err := opts.Printf(prompt)
-			if err != nil {
-				return "", err
+			var err error
+			editing := !options.Hidden && opts.isTerminal()
+			if !editing {
+				err = opts.Printf(prompt)
+				if err != nil {
+					return "", err
+				}
 			}
 			stop := options.MultiStop + "\n"
 			input := ""
@@ -284,11 +401,26 @@ func NewOptions() *Options {
 					}
 					line = string(bytes)
 					opts.Printf("\n")
+				} else if editing {
+					line, err = opts.editLine(prompt, options)
+					if err != nil && err != io.EOF {
+						return "", err
+					}
+					if err == io.EOF && options.ReturnEOF && input == "" {
+						return "", err
+					}
+					if err == nil {
+						line += "\n"
+					}
+					prompt = ""
 				} else {
 					line, err = opts.inReader.ReadString('\n')
 					if err != nil && err != io.EOF {
 						return "", err
 					}
+					if err == io.EOF && options.ReturnEOF && input == "" && line == "" {
+						return "", err
+					}
 				}
 				input += line
 				if !options.Multi || line == stop || err != nil {
@@ -297,18 +429,28 @@ func NewOptions() *Options {
 				}
 			}
 			input = strings.TrimRight(input, "\n")
-			if opts.QuitPrompt != "" && strings.EqualFold(input, opts.QuitPrompt) {
-				return input, ErrQuit
+			if err := opts.keywordError(input); err != nil {
+				return input, err
 			}
-			if opts.DiscardPrompt != "" && strings.EqualFold(input, opts.DiscardPrompt) {
-				return input, ErrDiscard
+			if editing && !options.Multi {
+				opts.addHistory(options.History, input)
 			}
 			return input, nil
 		},
+		PromptSelect: func(prompt string, options PromptSelectOptions) (string, error) {
+			return opts.promptSelectTerminal(prompt, options)
+		},
+		PromptSelectMany: func(prompt string, options PromptSelectManyOptions) ([]string, error) {
+			return opts.promptSelectManyTerminal(prompt, options)
+		},
 		RepromptOnInvalid:     5,
 		RepromptSliceElements: false,
 		RepromptMapValues:     false,
 
+		ShellPrompt: "> ",
+
+		PromptHistorySize: 100,
+
 		DisplayHelp: func(help string, prop *Property) {
 			opts.Printf("%s\n", help)
 		},
@@ -319,16 +461,266 @@ func NewOptions() *Options {
 
 // Sets the args for the current options. The given slice is unchanged, a copy is retained and updated on the Context during argument parsing.
 func (opts *Options) WithArgs(args []string) *Options {
-	opts.Args = args[:]
+	opts.Args = append([]string{}, args...)
 	opts.ArgsOriginal = args[:]
 	return opts
 }
 
-// Sets the context for the current options.
+// Replaces any @path arguments in Args and ArgsOriginal with the arguments in the file at path. The file is split into
+// arguments with SplitArgs and @path arguments in the file are expanded as well. Arguments after the ArgTerminator are
+// not expanded and an argument that starts with @@ is replaced with the argument without the first @ (ex: @@user is @user).
+func (opts *Options) ExpandArgFiles() error {
+	args, err := expandArgFiles(opts.Args, nil)
+	if err != nil {
+		return err
+	}
+	original, err := expandArgFiles(opts.ArgsOriginal, nil)
+	if err != nil {
+		return err
+	}
+	opts.Args = args
+	opts.ArgsOriginal = original
+	return nil
+}
+
+// An error returned when expanding argument files and a file includes itself.
+var ErrArgFileCycle = errors.New("argument file includes itself")
+
+func expandArgFiles(args []string, reading []string) ([]string, error) {
+	expanded := make([]string, 0, len(args))
+
+	for i, arg := range args {
+		switch {
+		case arg == ArgTerminator:
+			return append(expanded, args[i:]...), nil
+		case strings.HasPrefix(arg, "@@"):
+			expanded = append(expanded, arg[1:])
+		case len(arg) > 1 && arg[0] == '@':
+			path, err := filepath.Abs(arg[1:])
+			if err != nil {
+				return nil, err
+			}
+			for _, read := range reading {
+				if read == path {
+					return nil, fmt.Errorf("%w: %s", ErrArgFileCycle, arg[1:])
+				}
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			fileArgs, err := SplitArgs(string(data))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", arg[1:], err)
+			}
+			fileArgs, err = expandArgFiles(fileArgs, append(reading, path))
+			if err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, fileArgs...)
+		default:
+			expanded = append(expanded, arg)
+		}
+	}
+
+	return expanded, nil
+}
+
+// Returns the context for the current options.
 func (opts *Options) Context() context.Context {
+	if opts.ctx == nil {
+		return context.Background()
+	}
 	return opts.ctx
 }
 
+// Returns the value of the argument with the given prefix and name and removes it from the arguments.
+// The argument index is used while an instance is being captured, otherwise Args is searched.
+func (opts *Options) getArg(argPrefix string, name string, defaultValue string, flag bool) string {
+	if opts.args != nil {
+		return opts.args.get(argPrefix, name, defaultValue, flag)
+	}
+	return GetArg(name, defaultValue, &opts.Args, argPrefix, flag)
+}
+
+// Returns the value of the argument with the given prefix and name and whether it was given, and removes it from the arguments.
+func (opts *Options) lookupArg(argPrefix string, name string, flag bool) (string, bool) {
+	if opts.args != nil {
+		return opts.args.lookup(argPrefix, name, flag)
+	}
+	index := newArgIndex(opts.Args, opts.ArgPrefix)
+	value, given := index.lookup(argPrefix, name, flag)
+	opts.Args = index.remaining()
+	return value, given
+}
+
+// Returns how many times the argument with the given prefix and name was given and removes them from the arguments.
+func (opts *Options) countArg(argPrefix string, name string) (int, error) {
+	if opts.args != nil {
+		return opts.args.count(argPrefix, name)
+	}
+	index := newArgIndex(opts.Args, opts.ArgPrefix)
+	count, err := index.count(argPrefix, name)
+	opts.Args = index.remaining()
+	return count, err
+}
+
+// Returns the environment variable name for the argument name, which is EnvPrefix followed by the argument
+// without rootPrefix in upper case where each run of non-alphanumeric characters is an underscore.
+// ex: APP_SERVERS_1_PORT for --servers-1-port with the EnvPrefix APP_
+func (opts *Options) PathEnv(arg string, rootPrefix string) string {
+	if len(arg) >= len(rootPrefix) && strings.EqualFold(arg[:len(rootPrefix)], rootPrefix) {
+		arg = arg[len(rootPrefix):]
+	}
+	name := strings.Builder{}
+	separate := false
+	for _, r := range arg {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if separate && name.Len() > 0 {
+				name.WriteByte('_')
+			}
+			name.WriteRune(unicode.ToUpper(r))
+			separate = false
+		} else {
+			separate = true
+		}
+	}
+	return opts.EnvPrefix + name.String()
+}
+
+// Returns the importers which can be given as arguments, which are the DefaultImporters if Importers is nil.
+func (opts *Options) importers() []Importer {
+	if opts.Importers == nil {
+		return DefaultImporters()
+	}
+	return opts.Importers
+}
+
+// Returns the importer with the given name, ignoring case and non-alphanumeric characters, or nil if none exists.
+func (opts *Options) Importer(name string) *Importer {
+	normal := Normalize(name)
+	importers := opts.importers()
+	for i := range importers {
+		if Normalize(importers[i].Name) == normal {
+			return &importers[i]
+		}
+	}
+	return nil
+}
+
+// The path given to an importer to import the options input instead of a file, ex: --json -
+const ImportInput = "-"
+
+// An error returned when importing the options input and the options have no input.
+var ErrNoInput = errors.New("no input to import")
+
+// Reads the file at the path, or all of the options input if the path is ImportInput.
+// If ImportExpandEnv is true ${VAR} references in the data are expanded.
+func (opts *Options) readImport(path string) ([]byte, error) {
+	var data []byte
+	var err error
+	if path != ImportInput {
+		data, err = os.ReadFile(path)
+	} else if opts.inReader != nil {
+		data, err = io.ReadAll(opts.inReader)
+	} else {
+		return nil, ErrNoInput
+	}
+	if err != nil || !opts.ImportExpandEnv {
+		return data, err
+	}
+	return []byte(expandEnvRefs(string(data))), nil
+}
+
+// An importer or exporter and the path to the file given to it in the arguments.
+type fileArg[T any] struct {
+	handler *T
+	path    string
+}
+
+// Removes all arguments which the lookup returns a handler for and their paths from Args
+// and returns them in the order they were given.
+func takeFileArgs[T any](opts *Options, lookup func(name string) *T) []fileArg[T] {
+	files := make([]fileArg[T], 0)
+	args := make([]string, 0, len(opts.Args))
+
+	for i := 0; i < len(opts.Args); i++ {
+		arg := opts.Args[i]
+		if arg == ArgTerminator {
+			args = append(args, opts.Args[i:]...)
+			break
+		}
+		if !IsArgName(arg, opts.ArgPrefix) {
+			args = append(args, arg)
+			continue
+		}
+		name, path, hasPath := splitArgValue(arg[len(opts.ArgPrefix):])
+		handler := lookup(name)
+		if handler == nil {
+			args = append(args, arg)
+			continue
+		}
+		if !hasPath && i+1 < len(opts.Args) && !IsArgName(opts.Args[i+1], opts.ArgPrefix) {
+			i++
+			path = opts.Args[i]
+		}
+		if path != "" {
+			files = append(files, fileArg[T]{handler: handler, path: path})
+		}
+	}
+
+	opts.Args = args
+
+	return files
+}
+
+// Returns the exporters which can be given as arguments, which are the DefaultExporters if Exporters is nil.
+func (opts *Options) exporters() []Exporter {
+	if opts.Exporters == nil {
+		return DefaultExporters()
+	}
+	return opts.Exporters
+}
+
+// Returns the exporter with the given name, ignoring case and non-alphanumeric characters, or nil if none exists.
+func (opts *Options) Exporter(name string) *Exporter {
+	normal := Normalize(name)
+	exporters := opts.exporters()
+	for i := range exporters {
+		if Normalize(exporters[i].Name) == normal {
+			return &exporters[i]
+		}
+	}
+	return nil
+}
+
+// Returns the exporter for the argument name (ex: save-yaml) or nil if the name is not an export argument.
+func (opts *Options) exportArg(name string) *Exporter {
+	prefix := Normalize(opts.ArgExportPrefix)
+	normal := Normalize(name)
+	if prefix == "" || !strings.HasPrefix(normal, prefix) {
+		return nil
+	}
+	return opts.Exporter(normal[len(prefix):])
+}
+
+// Writes the data to the file at the path, or to the options output if the path is ImportInput.
+func (opts *Options) writeExport(path string, data []byte) error {
+	if path != ImportInput {
+		return os.WriteFile(path, data, 0644)
+	}
+	if opts.out == nil {
+		return nil
+	}
+	_, err := opts.out.Write(data)
+	return err
+}
+
+// Returns the registry currently capturing or executing a command, or nil if none.
+func (opts *Options) Registry() *Registry {
+	return opts.registry
+}
+
 // Sets the context for the current options.
 func (opts *Options) WithContext(ctx context.Context) *Options {
 	opts.ctx = ctx
@@ -352,10 +744,61 @@ func (opts *Options) RestorArgs() *Options {
 func (opts *Options) WithFiles(in *os.File, out *os.File) *Options {
 	opts.in = in
 	opts.out = out
-	opts.inReader = bufio.NewReader(in)
+	opts.inReader = bufio.NewReader(&contextReader{file: in, ctx: opts.Context})
 	return opts
 }
 
+// Reads from a file and stops waiting for the read when the context is done, returning the context error.
+// A read that was stopped continues in the background and the data it reads is returned by the next read.
+type contextReader struct {
+	file     *os.File
+	ctx      func() context.Context
+	pending  chan contextRead
+	buffered []byte
+}
+
+// The result of reading from a file in the background.
+type contextRead struct {
+	data []byte
+	err  error
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if len(r.buffered) > 0 {
+		n := copy(p, r.buffered)
+		r.buffered = r.buffered[n:]
+		return n, nil
+	}
+
+	ctx := r.ctx()
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if r.pending == nil {
+		if ctx.Done() == nil {
+			return r.file.Read(p)
+		}
+		pending := make(chan contextRead, 1)
+		size := len(p)
+		go func() {
+			data := make([]byte, size)
+			n, err := r.file.Read(data)
+			pending <- contextRead{data: data[:n], err: err}
+		}()
+		r.pending = pending
+	}
+
+	select {
+	case read := <-r.pending:
+		r.pending = nil
+		n := copy(p, read.data)
+		r.buffered = read.data[n:]
+		return n, read.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
 // Clears all files used during prompting, effectively disabling prompting unless ForcePrompt is specified.
 func (opts *Options) ClearFiles() *Options {
 	opts.in = nil
@@ -371,6 +814,7 @@ func (opts *Options) Std() *Options {
 
 // Enables argument parsing using the current running programs arguments.
 func (opts *Options) Cli() *Options {
+	opts.ProgramName = filepath.Base(os.Args[0])
 	return opts.WithArgs(os.Args[1:])
 }
 
@@ -437,6 +881,10 @@ type PromptOptions struct {
 	Prop *Property
 	// The valid inputs and automatic translations. The matching and translation is done before converting it to the desired type.
 	Choices PromptChoices
+	// The text of the current value, which is selected initially when prompting for one of the choices.
+	Current string
+	// The key answers are recalled and saved under when prompting on a terminal, or empty for no history.
+	History string
 	// A custom validation function for the text, before its parsed.
 	ValidateText func(text string) error
 	// A custom validation function for the text, before its parsed.
@@ -445,11 +893,16 @@ type PromptOptions struct {
 
 // Generates the once options from PromptOptions
 func (po PromptOptions) toOnce() PromptOnceOptions {
-	return PromptOnceOptions{
+	once := PromptOnceOptions{
 		Multi:     po.Multi,
 		MultiStop: po.MultiStop,
 		Hidden:    po.Hidden,
 	}
+	if !po.Hidden {
+		once.History = po.History
+		once.Completions = po.Choices.Texts()
+	}
+	return once
 }
 
 // The current status of the prompt, so useful prompt text can be generated.
@@ -475,6 +928,8 @@ func (opts *Options) Prompt(options PromptOptions) (any, error) {
 	prompt := options.Prompt
 	lastError := ErrNoPrompt
 
+	opts.prompts++
+
 	for i := 0; i <= options.Tries; i++ {
 		status.PromptCount = i
 		if options.GetPrompt != nil {
@@ -484,7 +939,7 @@ func (opts *Options) Prompt(options PromptOptions) (any, error) {
 			}
 		}
 
-		input, err := opts.PromptOnce(prompt, once)
+		input, err := opts.promptInput(prompt, once, options)
 		if err != nil {
 			return nil, err
 		}
@@ -606,6 +1061,34 @@ func (opts *Options) Prompt(options PromptOptions) (any, error) {
 	return nil, lastError
 }
 
+// Prompts for the input with PromptSelect if there are choices to select from, otherwise PromptOnce.
+func (opts *Options) promptInput(prompt string, once PromptOnceOptions, options PromptOptions) (string, error) {
+	if opts.PromptSelect != nil && options.Choices.HasChoices() && !once.Multi && !once.Hidden {
+		keywords := []string{opts.HelpPrompt, opts.QuitPrompt, opts.DiscardPrompt, opts.BackPrompt}
+		input, err := opts.PromptSelect(prompt, getPromptSelectOptions(options.Choices, options.Current, keywords))
+		if err == nil {
+			err = opts.keywordError(input)
+		}
+		if err != ErrSelectUnavailable {
+			return input, err
+		}
+	}
+	return opts.PromptOnce(prompt, once)
+}
+
+// Returns the error for the input if it's the QuitPrompt, DiscardPrompt, or BackPrompt (ignoring case), otherwise nil.
+func (opts *Options) keywordError(input string) error {
+	switch {
+	case opts.QuitPrompt != "" && strings.EqualFold(input, opts.QuitPrompt):
+		return ErrQuit
+	case opts.DiscardPrompt != "" && strings.EqualFold(input, opts.DiscardPrompt):
+		return ErrDiscard
+	case opts.BackPrompt != "" && strings.EqualFold(input, opts.BackPrompt):
+		return ErrBack
+	}
+	return nil
+}
+
 // Context that is changed during the prompt process.
 type PromptContext struct {
 	// If the user is currently being prompted for a map key.
@@ -652,6 +1135,12 @@ type PromptOnceOptions struct {
 	Multi     bool
 	Hidden    bool
 	MultiStop string
+	// If io.EOF should be returned when the input has ended and nothing was read.
+	ReturnEOF bool
+	// The key answers are recalled and saved under when editing on a terminal, or empty for no history.
+	History string
+	// The text tab completes to when editing on a terminal.
+	Completions []string
 }
 
 // Creates a parsed template and panics if it's invalid.
@@ -1,11 +1,14 @@
 package cmdgo
 
 import (
+	"context"
 	"encoding"
 	"fmt"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 type keyValue struct {
@@ -251,3 +254,236 @@ func TestPrompt(t *testing.T) {
 		}
 	}
 }
+
+func TestPromptContext(t *testing.T) {
+	type TimeoutCommand struct {
+		Name string `default:"anon" prompt-options:"timeout:20ms"`
+		Age  int
+	}
+
+	in, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	defer writer.Close()
+
+	// The name times out and keeps its default, the age waits until it's written.
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		writer.WriteString("30\n")
+	}()
+
+	actual := TimeoutCommand{}
+	inst := GetInstance(&actual)
+	opts := NewOptions().WithFiles(in, nil)
+	err = inst.Capture(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.Name != "anon" || actual.Age != 30 {
+		t.Errorf("Expected the default name and age 30 but got %+v", actual)
+	}
+
+	// The context deadline stops prompting.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	actual = TimeoutCommand{}
+	err = inst.Capture(opts.WithContext(ctx))
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected the deadline to be exceeded but got %v", err)
+	}
+
+	// What was typed after the deadline is read by the next prompt.
+	writer.WriteString("Bob\n40\n")
+
+	err = inst.Capture(opts.WithContext(context.Background()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.Name != "Bob" || actual.Age != 40 {
+		t.Errorf("Expected Bob and age 40 but got %+v", actual)
+	}
+}
+
+func TestCaptureCancel(t *testing.T) {
+	type CancelCommand struct {
+		First  string
+		Second string
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	registry := CreateRegistry([]Entry{
+		{Name: "cancel", Command: CancelCommand{}},
+	})
+
+	prompts := []string{}
+	opts := NewOptions().WithContext(ctx).WithArgs([]string{"cancel", "--interactive"})
+	opts.ForcePrompt = true
+	opts.PromptOnce = func(prompt string, options PromptOnceOptions) (string, error) {
+		prompts = append(prompts, prompt)
+		cancel()
+		return "first", nil
+	}
+
+	_, err := registry.Capture(opts)
+	if err != context.Canceled {
+		t.Errorf("Expected capture to be canceled but got %v", err)
+	}
+	if len(prompts) != 1 {
+		t.Errorf("Expected one prompt before canceling but got %v", prompts)
+	}
+
+	err = registry.Execute(opts.WithArgs([]string{"cancel"}))
+	if err != context.Canceled {
+		t.Errorf("Expected execute to be canceled but got %v", err)
+	}
+}
+
+type BackAddress struct {
+	City string
+	Zip  string
+}
+
+type BackDynamic struct {
+	Name string
+	City string
+}
+
+func (bd *BackDynamic) Update(opts *Options, updated *Property, instance *Instance) error {
+	instance.PropertyMap[Normalize("City")].HidePrompt = bd.Name == "skip"
+	return nil
+}
+
+func TestBackPrompt(t *testing.T) {
+	type BackSimple struct {
+		Name string
+		Age  int
+	}
+	type BackSlice struct {
+		Tags []string `prompt-options:"start:-" max:"2"`
+	}
+	type BackOutOfSlice struct {
+		Name string
+		Tags []string `prompt-options:"start:-" max:"1"`
+	}
+	type BackStruct struct {
+		Name    string
+		Address BackAddress `prompt-options:"start:-"`
+	}
+	type BackMap struct {
+		Labels map[string]string `prompt-options:"start:-" max:"2"`
+	}
+	type BackSkipped struct {
+		Name string
+		Age  int `prompt:"-" default:"30"`
+		City string
+	}
+	type BackArray struct {
+		Pair [2]string `prompt-options:"start:-"`
+	}
+
+	tests := []struct {
+		name     string
+		command  any
+		inputs   []string
+		expected any
+	}{
+		{
+			name:     "simple",
+			command:  &BackSimple{},
+			inputs:   []string{"Bob", "back!", "Rob", "30"},
+			expected: BackSimple{Name: "Rob", Age: 30},
+		},
+		{
+			name:     "first",
+			command:  &BackSimple{},
+			inputs:   []string{"back!", "Bob", "30"},
+			expected: BackSimple{Name: "Bob", Age: 30},
+		},
+		{
+			name:     "slice element",
+			command:  &BackSlice{},
+			inputs:   []string{"a", "y", "back!", "b", "y", "c"},
+			expected: BackSlice{Tags: []string{"b", "c"}},
+		},
+		{
+			name:     "slice more",
+			command:  &BackSlice{},
+			inputs:   []string{"a", "back!", "", "y", "c"},
+			expected: BackSlice{Tags: []string{"a", "c"}},
+		},
+		{
+			name:     "out of slice",
+			command:  &BackOutOfSlice{},
+			inputs:   []string{"Bob", "back!", "Rob", "x"},
+			expected: BackOutOfSlice{Name: "Rob", Tags: []string{"x"}},
+		},
+		{
+			name:     "struct",
+			command:  &BackStruct{},
+			inputs:   []string{"Bob", "back!", "Rob", "Town", "back!", "City", "12345"},
+			expected: BackStruct{Name: "Rob", Address: BackAddress{City: "City", Zip: "12345"}},
+		},
+		{
+			name:     "out of struct",
+			command:  &BackStruct{},
+			inputs:   []string{"Bob", "back!", "Rob", "City", "12345"},
+			expected: BackStruct{Name: "Rob", Address: BackAddress{City: "City", Zip: "12345"}},
+		},
+		{
+			name:     "map",
+			command:  &BackMap{},
+			inputs:   []string{"a", "1", "y", "back!", "b", "2", "y", "c", "back!", "d", "4"},
+			expected: BackMap{Labels: map[string]string{"b": "2", "d": "4"}},
+		},
+		{
+			name:     "array",
+			command:  &BackArray{},
+			inputs:   []string{"a", "back!", "b", "c"},
+			expected: BackArray{Pair: [2]string{"b", "c"}},
+		},
+		{
+			name:     "skipped",
+			command:  &BackSkipped{},
+			inputs:   []string{"Bob", "back!", "Rob", "Town"},
+			expected: BackSkipped{Name: "Rob", Age: 30, City: "Town"},
+		},
+		{
+			name:     "dynamic",
+			command:  &BackDynamic{},
+			inputs:   []string{"Bob", "back!", "skip"},
+			expected: BackDynamic{Name: "skip"},
+		},
+	}
+
+	for _, test := range tests {
+		inputs := test.inputs
+		opts := NewOptions()
+		opts.ForcePrompt = true
+		opts.PromptOnce = func(prompt string, options PromptOnceOptions) (string, error) {
+			if len(inputs) == 0 {
+				return "", ErrQuit
+			}
+			input := inputs[0]
+			inputs = inputs[1:]
+			if input == opts.BackPrompt {
+				return input, ErrBack
+			}
+			return input, nil
+		}
+
+		inst := GetInstance(test.command)
+		err := inst.Capture(opts)
+		if err != nil {
+			t.Errorf("Test [%s] failed with error %v", test.name, err)
+		} else if len(inputs) > 0 {
+			t.Errorf("Test [%s] did not prompt for %v", test.name, inputs)
+		} else if !equalsJson(test.command, test.expected) {
+			t.Errorf("Test [%s] expected %s but got %s", test.name, toJson(test.expected), toJson(test.command))
+		}
+	}
+}
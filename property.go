@@ -2,12 +2,16 @@ package cmdgo
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
+	"unicode/utf8"
 )
 
 // A command property parsed from a command struct.
@@ -36,6 +40,8 @@ type Property struct {
 	InputHidden bool
 	// How many tries to get the input. Overrides Context settings. ex: `prompt-options:"tries:4"`
 	PromptTries int
+	// How long to wait for the input before keeping the current or default value. ex: `prompt-options:"timeout:30s"`
+	PromptTimeout time.Duration
 	// If we should verify the input by reprompting. ex: `prompt-options:"verify"`
 	PromptVerify bool
 	// If the property should reprompt given slice and map values. ex `prompt-options="reprompt"`
@@ -58,10 +64,25 @@ type Property struct {
 	Max *float64
 	// Specified with the tag `env:"a,b"`
 	Env []string
+	// If the property is also populated by the environment variable named after its path. ex: `env:"auto"`
+	EnvAuto bool
 	// Arg name for this property. Defaults to the field name. ex: `arg:"my-flag"`
 	Arg string
+	// If this integer property is populated with the number of times its arg is given. ex: `count:"true"` with -vvv is 3
+	Count bool
+	// The single letter short arg name for this property. ex: `short:"v"` allows -v
+	Short string
+	// The position of the positional argument for this property, starting at 1. ex: `pos:"1"`
+	Pos int
+	// If this slice property is populated by all positional arguments after the numbered ones. ex: `pos:"rest"`
+	PosRest bool
+	// If an argument or prompted value of @path reads the value of this string or []byte property from the file at path. ex: `from-file:"true"`
+	FromFile bool
 	// Flags that represent how
 	Flags Flags[PropertyFlags]
+
+	// If this property is the value of a slice or array element or map key or value.
+	element bool
 }
 
 // Flags which are set on a property during capture.
@@ -78,43 +99,113 @@ const (
 	PropertyFlagEnv
 	// The property has had a value populated from the `default:""` struct tag.
 	PropertyFlagDefault
+	// The property has had a value populated from an environment variable named after its path, see Options.EnvAuto.
+	// Unlike the other environment variables and defaults captureValue keeps this flag, so a slice element or map value
+	// is added when one of its properties has a value from one of these. ex: APP_SERVERS_2_PORT adds a second server.
+	PropertyFlagEnvPath
 )
 
 // Returns whether this property can have its state loaded from environment variables
 // or default tags.
 func (prop Property) CanLoad() bool {
-	return !prop.IsIgnored() && prop.IsSimple() && prop.IsDefault()
+	return !prop.IsIgnored() && prop.IsSimple()
 }
 
 // Loads the initial value of the property from environment variables
-// or default tags specified on the struct fields.
+// or default tags specified on the struct fields. Environment variables replace
+// any current value (like an imported one) while the default tag is only used
+// when the property does not have a value. If an environment variable is not set
+// but the variable with opts.EnvFileSuffix is (ex: DB_PASSWORD_FILE) the value is
+// read from the file at its path, without the trailing newline. If the property has
+// `env:"auto"` or opts.EnvAuto is true the variable named after its path is checked last.
 func (prop *Property) Load(opts *Options) error {
 	if !prop.CanLoad() {
 		return nil
 	}
 
-	text := ""
-	flag := PropertyFlagNone
-	if prop.Env != nil && len(prop.Env) > 0 {
-		for _, env := range prop.Env {
-			envValue := os.Getenv(env)
-			if envValue != "" {
-				text = envValue
-				flag = PropertyFlagEnv
-				break
-			}
+	envs := prop.Env
+	autoEnv := ""
+	if (prop.EnvAuto || opts.EnvAuto) && !prop.element {
+		rootPrefix := opts.ArgPrefix
+		if opts.args != nil {
+			rootPrefix = opts.args.prefix
+		}
+		arg := prop.Arg
+		if arg == "-" {
+			arg = prop.Name
 		}
+		autoEnv = opts.PathEnv(opts.ArgPrefix+arg, rootPrefix)
+		envs = append(append([]string{}, envs...), autoEnv)
 	}
-	if text == "" && prop.Default != "" {
-		text = prop.Default
-		flag = PropertyFlagDefault
+
+	for _, env := range envs {
+		envFlags := PropertyFlagEnv
+		if env == autoEnv {
+			envFlags |= PropertyFlagEnvPath
+		}
+		envValue := os.Getenv(env)
+		if envValue != "" {
+			opts.recordOrigin(prop, Origin{Source: OriginEnv, Env: env})
+			return prop.Set(opts, envValue, envFlags)
+		}
+		if opts.EnvFileSuffix == "" {
+			continue
+		}
+		envFile := env + opts.EnvFileSuffix
+		if path := os.Getenv(envFile); path != "" {
+			contents, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", envFile, err)
+			}
+			opts.recordOrigin(prop, Origin{Source: OriginEnv, Env: envFile, File: path})
+			return prop.Set(opts, strings.TrimSuffix(strings.TrimSuffix(string(contents), "\n"), "\r"), envFlags)
+		}
 	}
-	if text != "" {
-		return prop.Set(opts, text, flag)
+	if prop.Default != "" && prop.IsDefault() {
+		defaultValue, err := prop.GetDefault(opts)
+		if err != nil {
+			return err
+		}
+		opts.recordOrigin(prop, Origin{Source: OriginDefault})
+		return prop.Set(opts, defaultValue, PropertyFlagDefault)
 	}
 	return nil
 }
 
+// Returns the default tag of the property with ${VAR} replaced by the value of the environment
+// variable VAR and then executed as a template given the values of the properties of the instance
+// being captured by name, ex: `default:"${HOME}/.cache/{{ .Name }}"`
+func (prop Property) GetDefault(opts *Options) (string, error) {
+	defaultValue := expandEnvRefs(prop.Default)
+	if !strings.Contains(defaultValue, "{{") {
+		return defaultValue, nil
+	}
+
+	tpl, err := template.New(prop.Name).Parse(defaultValue)
+	if err != nil {
+		return "", fmt.Errorf("default of %s: %w", prop.Name, err)
+	}
+
+	values := make(map[string]any)
+	if opts.instance != nil {
+		for _, instanceProp := range opts.instance.PropertyList {
+			concrete := concreteValue(instanceProp.Value)
+			if concrete.IsValid() {
+				values[instanceProp.Name] = concrete.Interface()
+			} else {
+				values[instanceProp.Name] = nil
+			}
+		}
+	}
+
+	out := bytes.Buffer{}
+	err = tpl.Execute(&out, values)
+	if err != nil {
+		return "", fmt.Errorf("default of %s: %w", prop.Name, err)
+	}
+	return out.String(), nil
+}
+
 // Returns whether this property can have its state loaded from arguments.
 func (prop Property) CanFromArgs() bool {
 	return prop.Arg != "-" && !prop.IsIgnored()
@@ -134,9 +225,14 @@ func (prop *Property) getArgValue(opts *Options) ArgValue {
 func (prop *Property) argValue(opts *Options) (bool, error) {
 	argValue := prop.getArgValue(opts)
 	if argValue != nil {
-		return true, argValue.FromArgs(opts, prop, func(arg string, defaultValue string) string {
-			return GetArg("", defaultValue, &opts.Args, arg, prop.IsBool())
+		wasArgs := prop.Flags.Is(MatchAny(PropertyFlagArgs))
+		err := argValue.FromArgs(opts, prop, func(arg string, defaultValue string) string {
+			return opts.getArg(arg, "", defaultValue, prop.IsBool())
 		})
+		if err == nil && !wasArgs && prop.Flags.Is(MatchAny(PropertyFlagArgs)) {
+			opts.recordArgOrigin(prop)
+		}
+		return true, err
 	}
 	return false, nil
 }
@@ -171,10 +267,80 @@ func (prop *Property) FromArgs(opts *Options) error {
 	return nil
 }
 
+// Returns whether this property can be populated from positional arguments.
+func (prop Property) IsPositional() bool {
+	return prop.Pos > 0 || prop.PosRest
+}
+
+// Loads the value of the property from the positional arguments given to it.
+// A slice property is given all values, otherwise the first value is used.
+func (prop *Property) FromPositionals(opts *Options, values []string) error {
+	if len(values) == 0 || prop.IsIgnored() {
+		return nil
+	}
+
+	opts.recordOrigin(prop, Origin{Source: OriginPositional, Position: prop.Pos})
+
+	if !prop.IsSlice() {
+		return prop.setArg(opts, values[0], PropertyFlagArgs)
+	}
+
+	sliceType := prop.ConcreteType()
+	slice := reflect.MakeSlice(sliceType, 0, len(values))
+	choices := prop.GetPromptChoices(opts)
+
+	for _, value := range values {
+		if choices != nil && choices.HasChoices() {
+			converted, err := choices.Convert(value)
+			if err != nil {
+				return err
+			}
+			value = converted
+		}
+		element := reflect.New(sliceType.Elem()).Elem()
+		err := SetString(element, value)
+		if err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, element)
+	}
+
+	setConcrete(prop.Value, slice)
+	prop.Flags.Set(PropertyFlagArgs)
+
+	return nil
+}
+
 func (prop *Property) fromArgsSimple(opts *Options) error {
-	value := GetArg(prop.Arg, "", &opts.Args, opts.ArgPrefix, prop.IsBool())
-	if value != "" {
-		return prop.Set(opts, value, PropertyFlagArgs)
+	if prop.Count {
+		count, err := opts.countArg(opts.ArgPrefix, prop.Arg)
+		if err != nil || count == 0 {
+			return err
+		}
+		opts.recordArgOrigin(prop)
+		return prop.Set(opts, strconv.Itoa(count), PropertyFlagArgs)
+	}
+
+	// An element given without a value is kept as an empty value, ex: --tags=
+	value, given := opts.lookupArg(opts.ArgPrefix, prop.Arg, prop.IsBool())
+	given = value != "" || (given && prop.element)
+	if given {
+		opts.recordArgOrigin(prop)
+	}
+	if prop.IsBool() && opts.ArgNegatePrefix != "" {
+		negated := opts.getArg(opts.ArgPrefix, opts.ArgNegatePrefix+prop.Arg, "", true)
+		if negated != "" {
+			negate, err := strconv.ParseBool(negated)
+			if err != nil {
+				return err
+			}
+			value = strconv.FormatBool(!negate)
+			given = true
+			opts.recordArgOrigin(prop)
+		}
+	}
+	if given {
+		return prop.setArg(opts, value, PropertyFlagArgs)
 	}
 
 	return nil
@@ -187,6 +353,9 @@ func (prop Property) promptStart(opts *Options) (bool, error) {
 	if prop.PromptStart == "-" {
 		return true, nil
 	}
+	if opts.CanPrompt() {
+		opts.prompts++
+	}
 	if start, err := opts.PromptStart(prop); !start || err != nil {
 		return false, err
 	}
@@ -230,7 +399,15 @@ func (prop *Property) getPromptValue(opts *Options) PromptCustom {
 func (prop *Property) promptValue(opts *Options) (bool, error) {
 	promptValue := prop.getPromptValue(opts)
 	if promptValue != nil {
-		return true, promptValue.Prompt(opts, prop)
+		if opts.CanPrompt() {
+			opts.prompts++
+		}
+		wasPrompt := prop.Flags.Is(MatchAny(PropertyFlagPrompt))
+		err := promptValue.Prompt(opts, prop)
+		if err == nil && !wasPrompt && prop.Flags.Is(MatchAny(PropertyFlagPrompt)) {
+			opts.recordOrigin(prop, Origin{Source: OriginPrompt})
+		}
+		return true, err
 	}
 	return false, nil
 }
@@ -247,8 +424,10 @@ func (prop *Property) fromArgsStruct(opts *Options) error {
 	}
 
 	argPrefix := opts.ArgPrefix
+	valuePath := opts.valuePath
 	defer func() {
 		opts.ArgPrefix = argPrefix
+		opts.valuePath = valuePath
 	}()
 
 	structTemplate := prop.getArgTemplate(argPrefix, reflect.Struct, opts.ArgStructTemplate)
@@ -258,6 +437,8 @@ func (prop *Property) fromArgsStruct(opts *Options) error {
 		return err
 	}
 
+	opts.valuePath = propertyPath(valuePath, prop)
+
 	flags, err := captureValue(opts, *prop, value, prefix)
 	if err != nil {
 		return err
@@ -278,11 +459,6 @@ func (prop *Property) fromArgsStruct(opts *Options) error {
 }
 
 func (prop *Property) fromArgsSlice(opts *Options) error {
-	start, err := prop.promptStart(opts)
-	if !start {
-		return err
-	}
-
 	value := prop.Value
 	sliceType := concreteType(value.Type())
 	if value.IsNil() {
@@ -291,20 +467,39 @@ func (prop *Property) fromArgsSlice(opts *Options) error {
 	slice := concreteValue(value)
 
 	elementType := sliceType.Elem()
+
+	additionalValues := !prop.HidePrompt
+
+	// When the elements are simple values with choices they are all selected at once after any in the arguments.
+	var selectChoices PromptChoices
+	if additionalValues && opts.CanPrompt() {
+		selectChoices = prop.getSelectManyChoices(opts, elementType)
+	}
+	selectMany := selectChoices.HasChoices()
+
+	if !selectMany {
+		start, err := prop.promptStart(opts)
+		if !start {
+			return err
+		}
+	}
+
 	argPrefix := opts.ArgPrefix
 	promptContext := opts.PromptContext
+	valuePath := opts.valuePath
 	defer func() {
 		opts.ArgPrefix = argPrefix
 		opts.PromptContext = promptContext
+		opts.valuePath = valuePath
 	}()
 
+	path := propertyPath(valuePath, prop)
+
 	length := slice.Len()
 
 	elementTemplate := prop.getArgTemplate(argPrefix, concreteType(elementType).Kind(), opts.ArgSliceTemplate)
 
-	additionalValues := !prop.HidePrompt
-
-	if (opts.RepromptSliceElements || prop.Reprompt) && opts.CanPrompt() {
+	if (opts.RepromptSliceElements || prop.Reprompt) && opts.CanPrompt() && !selectMany {
 		opts.PromptContext.Reprompt = true
 
 		for i := 0; i < length && additionalValues; i++ {
@@ -315,8 +510,16 @@ func (prop *Property) fromArgsSlice(opts *Options) error {
 			}
 
 			opts.PromptContext.forSlice(i)
+			opts.valuePath = elementPath(path, i)
 
 			loaded, err := captureValue(opts, *prop, slice.Index(i), elementPrefix)
+			if err == ErrBack {
+				if i == 0 {
+					return err
+				}
+				i -= 2
+				continue
+			}
 			keep := err != ErrDiscard
 			if err != nil && keep {
 				return err
@@ -336,6 +539,10 @@ func (prop *Property) fromArgsSlice(opts *Options) error {
 
 			if prop.Min == nil || length >= int(*prop.Min) {
 				more, err := prop.promptMore(opts)
+				if err == ErrBack {
+					i--
+					continue
+				}
 				if err != nil {
 					return err
 				}
@@ -348,6 +555,27 @@ func (prop *Property) fromArgsSlice(opts *Options) error {
 		opts.PromptContext.Reprompt = false
 	}
 
+	// The last element when going back to it, which is captured again with its current value.
+	var redo reflect.Value
+	back := func() bool {
+		if length == 0 {
+			return false
+		}
+		length--
+		redo = copyValue(slice.Index(length))
+		slice = slice.Slice(0, length)
+		return true
+	}
+
+	argLength := length
+	disablePrompt, forcePrompt := opts.DisablePrompt, opts.ForcePrompt
+	if selectMany {
+		opts.DisablePrompt, opts.ForcePrompt = true, false
+		defer func() {
+			opts.DisablePrompt, opts.ForcePrompt = disablePrompt, forcePrompt
+		}()
+	}
+
 	for additionalValues {
 		elementTemplate.Index = length + opts.ArgStartIndex
 		elementPrefix, err := elementTemplate.get()
@@ -356,15 +584,29 @@ func (prop *Property) fromArgsSlice(opts *Options) error {
 		}
 
 		opts.PromptContext.forSlice(length)
+		opts.valuePath = elementPath(path, length)
 
-		element, loaded, err := captureType(opts, *prop, elementType, elementPrefix)
+		var element reflect.Value
+		var loaded Flags[PropertyFlags]
+		if redo.IsValid() {
+			element, redo = redo, reflect.Value{}
+			loaded, err = captureValue(opts, *prop, element, elementPrefix)
+		} else {
+			element, loaded, err = captureType(opts, *prop, elementType, elementPrefix)
+		}
+		if err == ErrBack {
+			if !back() {
+				return err
+			}
+			continue
+		}
 		keep := err != ErrDiscard
 		if err != nil && keep {
 			return err
 		}
 
 		if keep {
-			if loaded.IsEmpty() && (prop.Min == nil || length+1 >= int(*prop.Min)) && !opts.CanPrompt() {
+			if loaded.IsEmpty() && (selectMany || prop.Min == nil || length+1 >= int(*prop.Min)) && !opts.CanPrompt() {
 				break
 			}
 
@@ -379,6 +621,9 @@ func (prop *Property) fromArgsSlice(opts *Options) error {
 
 		if prop.Min == nil || length >= int(*prop.Min) {
 			more, err := prop.promptMore(opts)
+			if err == ErrBack && back() {
+				continue
+			}
 			if err != nil {
 				return err
 			}
@@ -388,11 +633,30 @@ func (prop *Property) fromArgsSlice(opts *Options) error {
 		}
 	}
 
+	opts.DisablePrompt, opts.ForcePrompt = disablePrompt, forcePrompt
+
+	if selectMany && length == argLength {
+		opts.PromptContext.reset()
+		opts.valuePath = valuePath
+
+		selected, err := prop.promptSelectMany(opts, slice, selectChoices)
+		if err != nil {
+			return err
+		}
+		if selected.IsValid() {
+			slice = selected
+			length = slice.Len()
+			setConcrete(prop.Value, slice)
+			prop.Flags.Set(PropertyFlagPrompt)
+			opts.recordOrigin(prop, Origin{Source: OriginPrompt})
+		}
+	}
+
 	if length > 0 {
 		setConcrete(prop.Value, slice)
 	}
 
-	err = prop.promptEnd(opts)
+	err := prop.promptEnd(opts)
 	if err != nil {
 		return err
 	}
@@ -400,6 +664,70 @@ func (prop *Property) fromArgsSlice(opts *Options) error {
 	return nil
 }
 
+// Returns the choices of the elements of this slice if they can be selected all at once, which is
+// when the elements are simple values with choices and no custom prompting or argument handling.
+func (prop *Property) getSelectManyChoices(opts *Options, elementType reflect.Type) PromptChoices {
+	instance := GetSubInstance(initializeType(elementType), *prop)
+	if len(instance.PropertyList) != 1 {
+		return nil
+	}
+	element := instance.PropertyList[0]
+	if !element.element || !element.IsSimple() || element.getPromptValue(opts) != nil || element.getArgValue(opts) != nil {
+		return nil
+	}
+	return element.GetPromptChoices(opts)
+}
+
+// Prompts for all the elements of this slice at once from the choices, with the elements of the slice selected initially.
+// Returns the slice of the selected values, or an invalid value if the elements should not change.
+func (prop *Property) promptSelectMany(opts *Options, slice reflect.Value, choices PromptChoices) (reflect.Value, error) {
+	current := make([]string, slice.Len())
+	for i := range current {
+		current[i] = fmt.Sprint(concreteValue(slice.Index(i)).Interface())
+	}
+
+	min, max := 0, 0
+	if prop.Min != nil {
+		min = int(*prop.Min)
+	}
+	if prop.Max != nil {
+		max = int(*prop.Max)
+	}
+
+	tries := opts.RepromptOnInvalid
+	if prop.PromptTries > 0 {
+		tries = prop.PromptTries
+	}
+
+	promptTemplate := prop.getPromptTemplate(opts.PromptContext, opts.PromptTemplate)
+
+	var values []string
+	err := prop.promptWithTimeout(opts, func() (err error) {
+		values, err = opts.promptMany(func(status PromptStatus) (string, error) {
+			promptTemplate.updateStatus(status)
+
+			return promptTemplate.get()
+		}, prop, choices, current, min, max, tries)
+		return err
+	})
+	if err != nil || values == nil {
+		return reflect.Value{}, err
+	}
+
+	elementType := concreteType(slice.Type()).Elem()
+	selected := reflect.MakeSlice(concreteType(slice.Type()), 0, len(values))
+	for _, value := range values {
+		element := initializeType(elementType)
+		err := SetString(element, value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		selected = reflect.Append(selected, element)
+	}
+
+	return selected, nil
+}
+
 func (prop *Property) fromArgsArray(opts *Options) error {
 	start, err := prop.promptStart(opts)
 	if !start {
@@ -414,13 +742,16 @@ func (prop *Property) fromArgsArray(opts *Options) error {
 	array := concreteValue(value)
 
 	argPrefix := opts.ArgPrefix
+	valuePath := opts.valuePath
 	defer func() {
 		opts.ArgPrefix = argPrefix
+		opts.valuePath = valuePath
 	}()
 
 	argFlags := Flags[PropertyFlags]{}
 
 	elementTemplate := prop.getArgTemplate(argPrefix, concreteType(arrayType.Elem()).Kind(), opts.ArgArrayTemplate)
+	path := propertyPath(valuePath, prop)
 
 	for i := 0; i < arrayType.Len(); i++ {
 		elementTemplate.Index = i + opts.ArgStartIndex
@@ -431,7 +762,13 @@ func (prop *Property) fromArgsArray(opts *Options) error {
 			return err
 		}
 
+		opts.valuePath = elementPath(path, i)
+
 		loaded, err := captureValue(opts, *prop, element, elementPrefix)
+		if err == ErrBack && i > 0 {
+			i -= 2
+			continue
+		}
 		if err != nil {
 			return err
 		}
@@ -470,11 +807,15 @@ func (prop *Property) fromArgsMap(opts *Options) error {
 
 	argPrefix := opts.ArgPrefix
 	promptContext := opts.PromptContext
+	valuePath := opts.valuePath
 	defer func() {
 		opts.ArgPrefix = argPrefix
 		opts.PromptContext = promptContext
+		opts.valuePath = valuePath
 	}()
 
+	path := propertyPath(valuePath, prop)
+
 	argFlags := Flags[PropertyFlags]{}
 	length := mp.Len()
 
@@ -492,6 +833,7 @@ func (prop *Property) fromArgsMap(opts *Options) error {
 			mapValue := pointerOf(itr.Value()).Elem()
 
 			opts.PromptContext.forMapValue(mapKey.Interface())
+			opts.valuePath = elementPath(path, mapKey.Interface())
 
 			valueLoaded, err := captureValue(opts, *prop, mapValue, "")
 			valueKeep := err != ErrDiscard
@@ -524,6 +866,18 @@ func (prop *Property) fromArgsMap(opts *Options) error {
 		opts.PromptContext.Reprompt = false
 	}
 
+	// The keys added, so going back removes the last entry and prompts for it again.
+	added := []reflect.Value{}
+	back := func() bool {
+		if len(added) == 0 {
+			return false
+		}
+		mp.SetMapIndex(added[len(added)-1], reflect.Value{})
+		added = added[:len(added)-1]
+		length = mp.Len()
+		return true
+	}
+
 	for additionalValues {
 		keyTemplate.Index = length + opts.ArgStartIndex
 		valueTemplate.Index = length + opts.ArgStartIndex
@@ -534,8 +888,15 @@ func (prop *Property) fromArgsMap(opts *Options) error {
 		}
 
 		opts.PromptContext.forMapKey()
+		opts.valuePath = noValuePath
 
 		key, keyLoaded, err := captureType(opts, *prop, keyType, keyPrefix)
+		if err == ErrBack {
+			if !back() {
+				return err
+			}
+			continue
+		}
 		keyKeep := err != ErrDiscard
 		if err != nil && keyKeep {
 			return err
@@ -552,8 +913,12 @@ func (prop *Property) fromArgsMap(opts *Options) error {
 			}
 
 			opts.PromptContext.forMapValue(key.Interface())
+			opts.valuePath = elementPath(path, key.Interface())
 
 			value, valueLoaded, err := captureType(opts, *prop, valueType, valuePrefix)
+			if err == ErrBack {
+				continue
+			}
 			valueKeep := err != ErrDiscard
 			if err != nil && valueKeep {
 				return err
@@ -563,6 +928,7 @@ func (prop *Property) fromArgsMap(opts *Options) error {
 				argFlags.Set(keyLoaded.value | valueLoaded.value)
 				mp.SetMapIndex(key, value)
 				length = mp.Len()
+				added = append(added, key)
 
 				if prop.Max != nil && length >= int(*prop.Max) {
 					break
@@ -572,6 +938,9 @@ func (prop *Property) fromArgsMap(opts *Options) error {
 
 		if prop.Min == nil || length >= int(*prop.Min) {
 			more, err := prop.promptMore(opts)
+			if err == ErrBack && back() {
+				continue
+			}
 			if err != nil {
 				return err
 			}
@@ -644,6 +1013,7 @@ func captureValue(opts *Options, prop Property, value reflect.Value, argPrefix s
 		return Flags[PropertyFlags]{}, err
 	}
 
+	// Defaults and environment variables are the same for every element so they don't add one, unless they're named after its path.
 	important := instance.Flags()
 	important.Remove(PropertyFlagDefault | PropertyFlagEnv)
 
@@ -760,36 +1130,85 @@ func (prop *Property) promptSimple(opts *Options) error {
 		tries = prop.PromptTries
 	}
 
-	value, err := opts.Prompt(PromptOptions{
-		Prop:     prop,
-		Type:     prop.Type,
-		Hidden:   prop.InputHidden,
-		Verify:   prop.PromptVerify,
-		Multi:    prop.PromptMulti,
-		Help:     prop.Help,
-		Choices:  prop.GetPromptChoices(opts),
-		Regex:    prop.Regex,
-		Optional: prop.IsOptional() || !promptTemplate.IsDefault,
-		Tries:    tries,
-		GetPrompt: func(status PromptStatus) (string, error) {
-			promptTemplate.updateStatus(status)
-
-			return promptTemplate.get()
-		},
+	current := ""
+	if !prop.IsDefault() {
+		current = fmt.Sprint(prop.ConcreteValue())
+	}
+
+	// FromFile properties are prompted as text so @path can be given and []byte is set to the text.
+	promptType := prop.Type
+	if prop.FromFile {
+		promptType = typeOf[string]()
+	}
+
+	var value any
+	err := prop.promptWithTimeout(opts, func() (err error) {
+		value, err = opts.Prompt(PromptOptions{
+			Prop:     prop,
+			Type:     promptType,
+			Hidden:   prop.InputHidden,
+			Verify:   prop.PromptVerify,
+			Multi:    prop.PromptMulti,
+			Help:     prop.Help,
+			Choices:  prop.GetPromptChoices(opts),
+			Current:  current,
+			History:  historyKey(propertyPath(opts.valuePath, prop)),
+			Regex:    prop.Regex,
+			Optional: prop.IsOptional() || !promptTemplate.IsDefault,
+			Tries:    tries,
+			GetPrompt: func(status PromptStatus) (string, error) {
+				promptTemplate.updateStatus(status)
+
+				return promptTemplate.get()
+			},
+		})
+		return err
 	})
 
 	if err != nil {
 		return err
 	}
 
+	if value != nil && prop.FromFile {
+		err := prop.setArg(opts, value.(string), PropertyFlagPrompt)
+		if err == nil {
+			opts.recordOrigin(prop, Origin{Source: OriginPrompt})
+		}
+		return err
+	}
+
 	if value != nil {
 		prop.Flags.Set(PropertyFlagPrompt)
 		prop.Value.Set(reflect.ValueOf(value))
+		opts.recordOrigin(prop, Origin{Source: OriginPrompt})
 	}
 
 	return nil
 }
 
+// Prompts with the context of the options limited to the prompt timeout of this property, if it has one.
+// If the timeout is reached before input is given the property keeps its current or default value.
+func (prop *Property) promptWithTimeout(opts *Options, prompt func() error) error {
+	if prop.PromptTimeout <= 0 {
+		return prompt()
+	}
+
+	parent := opts.Context()
+	ctx, cancel := context.WithTimeout(parent, prop.PromptTimeout)
+	previous := opts.ctx
+	opts.ctx = ctx
+	defer func() {
+		cancel()
+		opts.ctx = previous
+	}()
+
+	err := prompt()
+	if errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+		return opts.Printf("\n")
+	}
+	return err
+}
+
 func (prop Property) Validate(opts *Options) error {
 	if !prop.IsIgnored() {
 		return nil
@@ -871,6 +1290,32 @@ func (prop Property) Size() float64 {
 	return 0
 }
 
+// Returns the argument or prompted input the property is set to. If the property is FromFile and the input is @path
+// the contents of the file at path are returned, and input that starts with @@ is returned without the first @.
+func (prop Property) fromFileInput(input string) (string, error) {
+	if !prop.FromFile || !strings.HasPrefix(input, "@") {
+		return input, nil
+	}
+	if strings.HasPrefix(input, "@@") {
+		return input[1:], nil
+	}
+	contents, err := os.ReadFile(input[1:])
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", prop.Name, err)
+	}
+	return string(contents), nil
+}
+
+// Sets the property to the argument value, which is read from a file if the property is FromFile and it's @path.
+func (prop *Property) setArg(opts *Options, input string, addFlags PropertyFlags) error {
+	input, err := prop.fromFileInput(input)
+	if err != nil {
+		return err
+	}
+	return prop.Set(opts, input, addFlags)
+}
+
+// Sets the property to the input, converting it with the choices of the property.
 func (prop *Property) Set(opts *Options, input string, addFlags PropertyFlags) error {
 	choices := prop.GetPromptChoices(opts)
 	if choices != nil && choices.HasChoices() {
@@ -880,6 +1325,11 @@ func (prop *Property) Set(opts *Options, input string, addFlags PropertyFlags) e
 		}
 		input = converted
 	}
+	if prop.isBytes() {
+		concreteValue(initialize(prop.Value)).SetBytes([]byte(input))
+		prop.Flags.Set(addFlags)
+		return nil
+	}
 	err := SetString(prop.Value, input)
 	if err == nil {
 		prop.Flags.Set(addFlags)
@@ -887,6 +1337,11 @@ func (prop *Property) Set(opts *Options, input string, addFlags PropertyFlags) e
 	return err
 }
 
+// Returns whether this is a FromFile property of []byte, which is set to the bytes of its text.
+func (prop Property) isBytes() bool {
+	return prop.FromFile && prop.ConcreteType() == typeOf[[]byte]()
+}
+
 func (prop *Property) GetPromptChoices(opts *Options) PromptChoices {
 	if prop.Choices != nil && prop.Choices.HasChoices() {
 		return prop.Choices
@@ -897,6 +1352,19 @@ func (prop *Property) GetPromptChoices(opts *Options) PromptChoices {
 	return nil
 }
 
+// Returns the completion candidates for the value of this property given the partial value.
+// Candidates come from HasCompletions if the value implements it, otherwise the prompt choices.
+func (prop *Property) GetCompletions(opts *Options, partial string) []string {
+	candidate := prop.Value
+	if candidate.CanAddr() {
+		candidate = candidate.Addr()
+	}
+	if hasCompletions, ok := candidate.Interface().(HasCompletions); ok {
+		return hasCompletions.GetCompletions(opts, prop, partial)
+	}
+	return prop.GetPromptChoices(opts).Texts()
+}
+
 func (prop Property) IsDefault() bool {
 	return isDefaultValue(prop.Value.Interface())
 }
@@ -922,6 +1390,11 @@ func (prop Property) IsBool() bool {
 	return prop.IsKind(reflect.Bool)
 }
 
+// Returns whether the arg of this property can be given without a value.
+func (prop Property) IsFlag() bool {
+	return prop.IsBool() || prop.Count
+}
+
 func (prop Property) IsSlice() bool {
 	return prop.IsKind(reflect.Slice)
 }
@@ -939,6 +1412,9 @@ func (prop Property) IsMap() bool {
 }
 
 func (prop Property) IsSimple() bool {
+	if prop.isBytes() {
+		return true
+	}
 	return !prop.IsKinds(map[reflect.Kind]struct{}{
 		reflect.Array:         {},
 		reflect.Slice:         {},
@@ -1029,6 +1505,12 @@ func getStructProperty(field reflect.StructField, value reflect.Value) Property
 					panic(err)
 				}
 				prop.PromptTries = int(tries)
+			case "timeout":
+				timeout, err := time.ParseDuration(value)
+				if err != nil {
+					panic(err)
+				}
+				prop.PromptTimeout = timeout
 			}
 		}
 	}
@@ -1054,15 +1536,60 @@ func getStructProperty(field reflect.StructField, value reflect.Value) Property
 	}
 
 	if env, ok := field.Tag.Lookup("env"); ok && env != "" {
-		prop.Env = strings.Split(env, ",")
+		for _, name := range strings.Split(env, ",") {
+			if strings.EqualFold(name, "auto") {
+				prop.EnvAuto = true
+			} else {
+				prop.Env = append(prop.Env, name)
+			}
+		}
 	}
 
 	if arg, ok := field.Tag.Lookup("arg"); ok {
-		prop.Arg = Normalize(arg)
+		if arg == "-" {
+			prop.Arg = arg
+		} else {
+			prop.Arg = Normalize(arg)
+		}
 	} else {
 		prop.Arg = prop.Name
 	}
 
+	if count, ok := field.Tag.Lookup("count"); ok {
+		prop.Count, _ = strconv.ParseBool(count)
+		if prop.Count && !isIntegerKind(concreteType(field.Type).Kind()) {
+			panic(fmt.Sprintf("count of %s can only be used on an integer", field.Name))
+		}
+	}
+
+	if short, ok := field.Tag.Lookup("short"); ok {
+		if utf8.RuneCountInString(short) != 1 {
+			panic(fmt.Sprintf("short of %s must be a single letter", field.Name))
+		}
+		prop.Short = short
+	}
+
+	if pos, ok := field.Tag.Lookup("pos"); ok {
+		if strings.EqualFold(pos, "rest") {
+			if concreteType(field.Type).Kind() != reflect.Slice {
+				panic(fmt.Sprintf("pos of %s can only be rest for a slice", field.Name))
+			}
+			prop.PosRest = true
+		} else if posInt, err := strconv.ParseInt(pos, 10, 32); err == nil && posInt > 0 {
+			prop.Pos = int(posInt)
+		} else {
+			panic(fmt.Sprintf("pos of %s is not a valid position or rest", field.Name))
+		}
+	}
+
+	if fromFile, ok := field.Tag.Lookup("from-file"); ok {
+		prop.FromFile, _ = strconv.ParseBool(fromFile)
+		fromFileType := concreteType(field.Type)
+		if prop.FromFile && fromFileType.Kind() != reflect.String && fromFileType != typeOf[[]byte]() {
+			panic(fmt.Sprintf("from-file of %s can only be used on a string or []byte", field.Name))
+		}
+	}
+
 	if min, ok := field.Tag.Lookup("min"); ok {
 		if minFloat, err := strconv.ParseFloat(min, 64); err == nil {
 			prop.Min = &minFloat
@@ -0,0 +1,261 @@
+package cmdgo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// The kind of source a captured value came from.
+type OriginSource string
+
+const (
+	// The value was not set during capture.
+	OriginNone OriginSource = ""
+	// The value came from the `default:""` struct tag.
+	OriginDefault OriginSource = "default"
+	// The value came from a discovered config file.
+	OriginConfig OriginSource = "config"
+	// The value came from a file given to an importer argument, ex: --json path.
+	OriginImport OriginSource = "import"
+	// The value came from an environment variable.
+	OriginEnv OriginSource = "env"
+	// The value came from an argument.
+	OriginArgs OriginSource = "args"
+	// The value came from a positional argument.
+	OriginPositional OriginSource = "positional"
+	// The value came from prompting the user.
+	OriginPrompt OriginSource = "prompt"
+)
+
+// Where a captured value came from.
+type Origin struct {
+	// The kind of source of the value.
+	Source OriginSource
+	// The environment variable the value came from when Source is OriginEnv.
+	Env string
+	// The config or imported file the value came from when Source is OriginConfig or OriginImport.
+	File string
+	// The argument the value came from when Source is OriginArgs, ex: --movies-1-title
+	Arg string
+	// The position of the argument in Options.ArgsOriginal when Source is OriginArgs (-1 if it's not in there),
+	// or the position of the positional argument when Source is OriginPositional (0 for rest).
+	Position int
+}
+
+// Returns a description of the origin, ex: env APP_DB_HOST or arg --db-host at 2
+func (origin Origin) String() string {
+	switch origin.Source {
+	case OriginEnv:
+		return fmt.Sprintf("env %s", origin.Env)
+	case OriginConfig, OriginImport:
+		return fmt.Sprintf("%s %s", origin.Source, origin.File)
+	case OriginArgs:
+		if origin.Arg == "" {
+			return string(origin.Source)
+		}
+		return fmt.Sprintf("arg %s at %d", origin.Arg, origin.Position)
+	case OriginPositional:
+		if origin.Position == 0 {
+			return "positional rest"
+		}
+		return fmt.Sprintf("positional %d", origin.Position)
+	case OriginNone:
+		return "-"
+	}
+	return string(origin.Source)
+}
+
+// The origins of the captured values by property path, ex: Profile.Name, Movies[0].Title, or Labels[a].
+// When the value of a slice, array, or map is set as a whole (ex: from positional arguments) only its path has an origin.
+type Provenance map[string]Origin
+
+// Returns the origin of the value at the path or of the nearest parent value with an origin.
+func (p Provenance) Get(path string) Origin {
+	for path != "" {
+		if origin, ok := p[path]; ok {
+			return origin
+		}
+		parent := strings.LastIndexAny(path, ".[")
+		if parent == -1 {
+			break
+		}
+		path = path[:parent]
+	}
+	return Origin{}
+}
+
+// A path used while capturing values which don't have a path and should not be recorded, like map keys.
+const noValuePath = "-"
+
+// Returns the path of the property given the path of the value it's on.
+func propertyPath(valuePath string, prop *Property) string {
+	switch {
+	case valuePath == noValuePath:
+		return noValuePath
+	case prop.element:
+		return valuePath
+	case valuePath == "":
+		return prop.Name
+	}
+	return valuePath + "." + prop.Name
+}
+
+// Returns the path of the slice or array element or map value at the index or key.
+func elementPath(path string, index any) string {
+	if path == noValuePath {
+		return noValuePath
+	}
+	return fmt.Sprintf("%s[%v]", path, index)
+}
+
+// Records the origin of the property value being captured if provenance is being recorded.
+func (opts *Options) recordOrigin(prop *Property, origin Origin) {
+	if opts.Provenance == nil {
+		return
+	}
+	path := propertyPath(opts.valuePath, prop)
+	if path == noValuePath {
+		return
+	}
+	opts.Provenance[path] = origin
+}
+
+// Records the origin of the property value taken from the last argument if provenance is being recorded.
+func (opts *Options) recordArgOrigin(prop *Property) {
+	if opts.Provenance == nil {
+		return
+	}
+	origin := Origin{Source: OriginArgs, Position: -1}
+	if opts.args != nil && opts.args.last != -1 {
+		origin.Position = opts.args.origin(opts.args.last)
+		origin.Arg, _, _ = splitArgValue(opts.args.args[opts.args.last])
+	}
+	opts.recordOrigin(prop, origin)
+}
+
+// Imports into the command and records the values the import changed as coming from the source and file.
+func (opts *Options) recordImport(source OriginSource, file string, command any, importer func() error) error {
+	if opts.Provenance == nil {
+		return importer()
+	}
+	before := snapshotValues(command)
+	err := importer()
+	if err != nil {
+		return err
+	}
+	for path, value := range snapshotValues(command) {
+		if previous, exists := before[path]; !exists || previous != value {
+			opts.Provenance[path] = Origin{Source: source, File: file}
+		}
+	}
+	return nil
+}
+
+// Returns the text of each value in the command by path.
+func snapshotValues(command any) map[string]string {
+	values := make(map[string]string)
+	walkValues(GetInstance(command), "", false, func(path string, prop *Property, hidden bool) {
+		values[path] = fmt.Sprint(prop.ConcreteValue())
+	})
+	return values
+}
+
+// Calls fn for each simple value in the instance with its path and whether it or a value it's in has hidden input,
+// including the values of nested structs, slices, arrays, and maps. Values with custom arg handling are not walked into.
+func walkValues(inst Instance, path string, hidden bool, fn func(path string, prop *Property, hidden bool)) {
+	for _, prop := range inst.PropertyList {
+		if prop.IsIgnored() || (prop.IsOptional() && prop.IsNil()) {
+			continue
+		}
+
+		propPath := propertyPath(path, prop)
+		propHidden := hidden || prop.InputHidden
+
+		if prop.IsSimple() || prop.getArgValue(nil) != nil || prop.getPromptValue(nil) != nil {
+			fn(propPath, prop, propHidden)
+			continue
+		}
+
+		value := concreteValue(prop.Value)
+
+		switch {
+		case prop.IsStruct():
+			walkValues(GetSubInstance(value, *prop), propPath, propHidden, fn)
+		case prop.IsSlice(), prop.IsArray():
+			for i := 0; i < value.Len(); i++ {
+				walkValues(GetSubInstance(value.Index(i), *prop), elementPath(propPath, i), propHidden, fn)
+			}
+		case prop.IsMap():
+			keys := value.MapKeys()
+			sort.Slice(keys, func(i, j int) bool {
+				return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+			})
+			for _, key := range keys {
+				mapValue := pointerOf(value.MapIndex(key)).Elem()
+				walkValues(GetSubInstance(mapValue, *prop), elementPath(propPath, key.Interface()), propHidden, fn)
+			}
+		}
+	}
+}
+
+// A value of a captured command and where it came from.
+type ExplainedValue struct {
+	// The path of the value, ex: Movies[0].Title
+	Path string
+	// The text of the value, which is masked for properties with hidden input.
+	Value string
+	// Where the value came from.
+	Origin Origin
+}
+
+// Returns the ArgExplain argument with the ArgPrefix, or an empty string if ArgExplain is empty or
+// one of the given arguments of the command is the same argument.
+func (opts *Options) explainArg(infos []argInfo) string {
+	if opts.ArgExplain == "" {
+		return ""
+	}
+	arg := opts.ArgPrefix + opts.ArgExplain
+	for _, info := range infos {
+		if Normalize(info.Arg) == Normalize(arg) {
+			return ""
+		}
+	}
+	return arg
+}
+
+// The text displayed in place of the values of properties with hidden input when explaining.
+var ExplainMask = "********"
+
+// Returns every value of the command and where it came from given the provenance recorded while capturing it.
+// The values of properties with hidden input (`prompt-options:"hidden"`) and any values within them are masked.
+func Explain(command any, provenance Provenance) []ExplainedValue {
+	explained := make([]ExplainedValue, 0)
+	walkValues(GetInstance(command), "", false, func(path string, prop *Property, hidden bool) {
+		value := fmt.Sprint(prop.ConcreteValue())
+		if hidden {
+			value = ExplainMask
+		}
+		explained = append(explained, ExplainedValue{
+			Path:   path,
+			Value:  value,
+			Origin: provenance.Get(path),
+		})
+	})
+	return explained
+}
+
+// Prints a table of the explained values to the options output.
+func (opts *Options) displayExplain(explained []ExplainedValue) error {
+	out := strings.Builder{}
+	table := tabwriter.NewWriter(&out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "PATH\tVALUE\tORIGIN")
+	for _, value := range explained {
+		fmt.Fprintf(table, "%s\t%s\t%s\n", value.Path, strconv.Quote(value.Value), value.Origin)
+	}
+	table.Flush()
+
+	return opts.Printf("%s", out.String())
+}
@@ -0,0 +1,159 @@
+package cmdgo
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+type ProvenanceServer struct {
+	Host string `env:"PROVENANCE_HOST"`
+	Port int    `default:"8080"`
+}
+
+type ProvenanceCommand struct {
+	Name     string
+	Password string `prompt-options:"hidden"`
+	Server   ProvenanceServer
+	Tags     []string
+	Labels   map[string]string
+	Verbose  int      `count:"true" short:"v"`
+	Files    []string `pos:"rest"`
+}
+
+func TestProvenance(t *testing.T) {
+	t.Setenv("PROVENANCE_HOST", "example.com")
+
+	path := t.TempDir() + "/provenance.json"
+	err := os.WriteFile(path, []byte(`{"Name": "imported", "Tags": ["a"]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := CreateRegistry([]Entry{
+		{Name: "provenance", Command: ProvenanceCommand{}},
+	})
+
+	opts := NewOptions().WithArgs([]string{
+		"provenance", "--json", path, "--password", "secret", "--tags", "b", "--tags", "c",
+		"--labels-key", "x", "--labels-value", "y", "-vv", "one", "two",
+	})
+	opts.Provenance = Provenance{}
+
+	_, err = registry.Capture(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]Origin{
+		"Name":        {Source: OriginImport, File: path},
+		"Password":    {Source: OriginArgs, Arg: "--password", Position: 3},
+		"Server.Host": {Source: OriginEnv, Env: "PROVENANCE_HOST"},
+		"Server.Port": {Source: OriginDefault},
+		"Tags[0]":     {Source: OriginImport, File: path},
+		"Tags[1]":     {Source: OriginArgs, Arg: "--tags", Position: 5},
+		"Tags[2]":     {Source: OriginArgs, Arg: "--tags", Position: 7},
+		"Labels[x]":   {Source: OriginArgs, Arg: "--labels-value", Position: 11},
+		"Verbose":     {Source: OriginArgs, Arg: "--verbose", Position: 13},
+		"Files[1]":    {Source: OriginPositional},
+	}
+	for path, origin := range expected {
+		if actual := opts.Provenance.Get(path); actual != origin {
+			t.Errorf("Test [%s] expected origin %+v but got %+v", path, origin, actual)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	t.Setenv("PROVENANCE_HOST", "example.com")
+
+	out, err := os.CreateTemp("", "explain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(out.Name())
+
+	registry := CreateRegistry([]Entry{
+		{Name: "provenance", Command: ProvenanceCommand{}},
+	})
+
+	opts := NewOptions().WithArgs([]string{"provenance", "--explain", "--name", "x", "--password", "secret"})
+	opts.WithFiles(nil, out)
+
+	cmd, err := registry.Capture(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd != nil {
+		t.Errorf("Expected no command to be returned when explaining")
+	}
+
+	printed, _ := os.ReadFile(out.Name())
+	expected := []string{
+		`PATH         VALUE          ORIGIN`,
+		`Name         "x"            arg --name at 2`,
+		`Password     "********"     arg --password at 4`,
+		`Server.Host  "example.com"  env PROVENANCE_HOST`,
+		`Server.Port  "8080"         default`,
+		`Verbose      "0"            -`,
+	}
+	actual := strings.Split(strings.TrimSpace(string(printed)), "\n")
+	for i := range actual {
+		actual[i] = strings.TrimRight(actual[i], " ")
+	}
+	if !equalsJson(actual, expected) {
+		t.Errorf("Expected explain:\n%s\nbut got:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
+
+type ExplainCommand struct {
+	Explain bool
+	Name    string
+}
+
+func TestExplainArg(t *testing.T) {
+	registry := CreateRegistry([]Entry{
+		{Name: "explain", Command: ExplainCommand{}},
+		{Name: "provenance", Command: ProvenanceCommand{}},
+	})
+
+	tests := []struct {
+		name     string
+		explain  string
+		args     []string
+		expected any
+	}{
+		{
+			name:     "disabled",
+			args:     []string{"provenance", "--explain", "--name", "x"},
+			expected: nil,
+		},
+		{
+			name:     "command property",
+			explain:  "explain",
+			args:     []string{"explain", "--explain", "--name", "x"},
+			expected: &ExplainCommand{Explain: true, Name: "x"},
+		},
+	}
+
+	for _, test := range tests {
+		opts := NewOptions().WithArgs(test.args)
+		opts.ArgExplain = test.explain
+		opts.StrictArgs = true
+
+		cmd, err := registry.Capture(opts)
+		if test.expected == nil {
+			if _, ok := err.(UnknownArgsError); !ok {
+				t.Errorf("Test [%s] expected an unknown args error but got %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test [%s] failed: %v", test.name, err)
+			continue
+		}
+		if !equalsJson(cmd, test.expected) {
+			t.Errorf("Test [%s] expected %+v but got %+v", test.name, test.expected, cmd)
+		}
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"errors"
-	"fmt"
-	"os"
 	"strconv"
 	"strings"
 
@@ -52,6 +50,14 @@ type Entry struct {
 	Command any
 	// A registry of sub commands. Either this or Command should be given.
 	Sub Registry
+	// If the command should not be displayed in help, completions, or matched by a partial name.
+	Hidden bool
+	// If the arguments after the command name should be left as-is in the options for the command to handle.
+	// Help, interactive, and importer arguments are not parsed and the command is not captured.
+	RawArgs bool
+	// The names of config files (without extension) to search for and import before capture, ex: "deploy"
+	// finds deploy.yaml, deploy.json, etc. If empty and Options.DiscoverConfig is true the name of the command is used.
+	ConfigNames []string
 }
 
 // Returns whether the registry is empty.
@@ -84,6 +90,7 @@ func (r Registry) EntriesAll() []Entry {
 			sub := entry.Sub.EntriesAll()
 			for _, subEntry := range sub {
 				subEntry.Name = entry.Name + " " + subEntry.Name
+				subEntry.Hidden = subEntry.Hidden || entry.Hidden
 				all = append(all, subEntry)
 			}
 		}
@@ -103,19 +110,84 @@ func (r Registry) Matches(namePartial string) []*Entry {
 		return []*Entry{}
 	}
 
-	matchMap := make(map[string]*Entry)
-	for key, entry := range r.entryMap {
-		if strings.HasPrefix(key, name) {
-			matchMap[entry.Name] = entry
+	matches := make([]*Entry, 0)
+	for _, entry := range r.entries {
+		if entry.Hidden {
+			continue
+		}
+		for _, key := range append([]string{entry.Name}, entry.Aliases...) {
+			if strings.HasPrefix(Normalize(key), name) {
+				matches = append(matches, entry)
+				break
+			}
 		}
 	}
 
-	matches := make([]*Entry, 0, len(matchMap))
-	for _, entry := range matchMap {
-		matches = append(matches, entry)
+	return matches
+}
+
+// Returns the non-hidden names and aliases of the commands in this registry.
+func (r Registry) names() []string {
+	names := make([]string, 0, len(r.entries))
+	for _, entry := range r.entries {
+		if !entry.Hidden {
+			names = append(names, entry.names()...)
+		}
 	}
+	return names
+}
 
-	return matches
+// Returns the sub registry reached by following the names, or an empty registry if the names do not lead to one.
+func (r Registry) subAt(namePartials []string) Registry {
+	registry := r
+	for _, name := range namePartials {
+		entry := registry.EntryFor(name)
+		if entry == nil || entry.Sub.IsEmpty() {
+			return NewRegistry()
+		}
+		registry = entry.Sub
+	}
+	return registry
+}
+
+// Returns the entry & depth which matches the names - going deep into the entry inner registries until
+// a command is found. If a name matches no entries a CommandNotFoundError is returned with suggestions,
+// if a name matches more than one entry an AmbiguousCommandError is returned, and if the names end
+// before a command is found a CommandNotFoundError is returned with an empty name.
+func (r Registry) Resolve(namePartials []string) (*Entry, int, error) {
+	registry := r
+	for depth := 0; ; depth++ {
+		if depth >= len(namePartials) {
+			return nil, depth - 1, CommandNotFoundError{
+				Path:        namePartials[:depth],
+				Suggestions: registry.names(),
+			}
+		}
+
+		name := namePartials[depth]
+		matches := registry.Matches(name)
+
+		if len(matches) == 0 {
+			return nil, depth, CommandNotFoundError{
+				Path:        namePartials[:depth],
+				Name:        name,
+				Suggestions: Suggest(name, registry.names()),
+			}
+		}
+		if len(matches) > 1 {
+			return nil, depth, AmbiguousCommandError{
+				Path:    namePartials[:depth],
+				Name:    name,
+				Matches: matches,
+			}
+		}
+
+		entry := matches[0]
+		if entry.Sub.IsEmpty() {
+			return entry, depth, nil
+		}
+		registry = entry.Sub
+	}
 }
 
 // Returns the entry which matches the name only if one entry does.
@@ -133,6 +205,9 @@ func (r Registry) EntryForDeep(namePartials []string) (*Entry, int) {
 	entry := r.EntryFor(namePartials[i])
 	for entry != nil {
 		if !entry.Sub.IsEmpty() {
+			if i+1 >= len(namePartials) {
+				return nil, i
+			}
 			i++
 			entry = entry.Sub.EntryFor(namePartials[i])
 		} else {
@@ -184,6 +259,11 @@ func (r Registry) ExecuteReturn(opts *Options) (any, error) {
 		return cmd, err
 	}
 
+	err = opts.Context().Err()
+	if err != nil {
+		return cmd, err
+	}
+
 	if executable, ok := cmd.(Executable); ok {
 		return cmd, executable.Execute(opts)
 	}
@@ -224,7 +304,36 @@ type CaptureImporter func(data []byte, target any) error
 // If no arguments are specified beyond the name then interactive mode is enabled by default.
 // Interactive (prompt) can be disabled entirely with "--interactive false".
 // Importers are also evaluted, like --json, --xml, and --yaml. The value following is the path to the file to import.
+// If the path is "-" the options input is imported and prompting is disabled.
+// Exporters like --save-yaml write the command to the path following them after it's captured and validated.
+// Values are applied in order of precedence: defaults, discovered config files, imported files in argument order (an importer can be given more
+// than once to merge several files), environment variables, arguments, and then prompting.
+// If Options.StrictArgs is true and arguments remain after capture an UnknownArgsError is returned.
+// If Options.ArgFiles is true then @path arguments are expanded before the command is found.
+// If Options.Provenance is not nil the origin of each value is recorded in it. With the Options.ArgExplain argument the
+// values of the captured command and their origins are printed instead and nil is returned so it's not executed.
 func (r Registry) Capture(opts *Options) (any, error) {
+	opts.registry = &r
+
+	err := opts.Context().Err()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.Args) > 0 {
+		if entry, depth := r.EntryForDeep(opts.Args); entry != nil && entry.RawArgs {
+			opts.Args = opts.Args[depth+1:]
+			return cloneDefault(entry.Command), nil
+		}
+	}
+
+	if opts.ArgFiles {
+		err := opts.ExpandArgFiles()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	names := []string{""}
 
 	argsLength := len(opts.Args)
@@ -241,12 +350,13 @@ func (r Registry) Capture(opts *Options) (any, error) {
 		names = opts.Args
 	}
 
-	command, depth := r.GetDeep(names)
-
-	if command == nil {
-		return nil, fmt.Errorf("command not found: %v", names[depth])
+	entry, depth, err := r.Resolve(names)
+	if err != nil {
+		return nil, err
 	}
 
+	command := cloneDefault(entry.Command)
+
 	if names[0] != "" {
 		opts.Args = opts.Args[depth+1:]
 	}
@@ -257,21 +367,47 @@ func (r Registry) Capture(opts *Options) (any, error) {
 	}
 
 	interactive, _ := strconv.ParseBool(GetArg("interactive", interactiveDefault, &opts.Args, opts.ArgPrefix, true))
+	explain := false
+	if opts.ArgExplain != "" {
+		infos, err := getArgInfos(opts, GetInstance(command))
+		if err != nil {
+			return nil, err
+		}
+		if opts.explainArg(infos) != "" {
+			explain, _ = strconv.ParseBool(GetArg(opts.ArgExplain, "", &opts.Args, opts.ArgPrefix, true))
+		}
+	}
 
-	for arg, importer := range CaptureImports {
-		path := GetArg(arg, "", &opts.Args, opts.ArgPrefix, false)
-		if path != "" {
-			imported, err := os.ReadFile(path)
-			if err != nil {
-				return nil, err
-			}
-			err = importer(imported, command)
-			if err != nil {
-				return nil, err
-			}
+	if explain && opts.Provenance == nil {
+		opts.Provenance = Provenance{}
+	}
+	for path := range opts.Provenance {
+		delete(opts.Provenance, path)
+	}
+
+	err = opts.importConfigFiles(entry, command)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, imported := range takeFileArgs(opts, opts.Importer) {
+		if imported.path == ImportInput {
+			interactive = false
+		}
+		data, err := opts.readImport(imported.path)
+		if err != nil {
+			return nil, err
+		}
+		err = opts.recordImport(OriginImport, imported.path, command, func() error {
+			return imported.handler.Import(data, command)
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	exports := takeFileArgs(opts, opts.exportArg)
+
 	if !interactive {
 		opts.DisablePrompt = true
 		defer func() {
@@ -280,15 +416,44 @@ func (r Registry) Capture(opts *Options) (any, error) {
 	}
 
 	commandInstance := GetInstance(command)
-	err := commandInstance.Capture(opts)
+	err = commandInstance.Capture(opts)
+	if err != nil {
+		return nil, err
+	}
 
+	err = commandInstance.CheckArgs(opts)
 	if err != nil {
 		return nil, err
 	}
 
+	for _, exported := range exports {
+		data, err := exported.handler.Export(ExportValue(command))
+		if err != nil {
+			return nil, err
+		}
+		err = opts.writeExport(exported.path, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if explain {
+		return nil, opts.displayExplain(Explain(command, opts.Provenance))
+	}
+
 	return command, nil
 }
 
+// An importer that can be given as an argument followed by a path, ex: --json path/to/file.json
+type Importer struct {
+	// The argument name of the importer.
+	Name string
+	// Applies the data in the file to the command.
+	Import CaptureImporter
+}
+
+// The importers available by name. The importers given in Options.Importers by default are these
+// in the order of DefaultImporterNames followed by any others in alphabetical order.
 var CaptureImports = map[string]CaptureImporter{
 	"json": func(data []byte, target any) error {
 		return json.Unmarshal(data, target)
@@ -299,4 +464,29 @@ var CaptureImports = map[string]CaptureImporter{
 	"xml": func(data []byte, target any) error {
 		return xml.Unmarshal(data, target)
 	},
+	"toml":     ImportToml,
+	"ini":      ImportIni,
+	"env-file": ImportEnvFile,
+}
+
+// The order of the built-in importers in Options.Importers.
+var DefaultImporterNames = []string{"json", "yaml", "xml", "toml", "ini", "env-file"}
+
+// Returns the importers in CaptureImports in the order of DefaultImporterNames
+// followed by any others in alphabetical order.
+func DefaultImporters() []Importer {
+	importers := make([]Importer, 0, len(CaptureImports))
+	added := make(map[string]bool)
+	for _, name := range DefaultImporterNames {
+		if importer, exists := CaptureImports[name]; exists {
+			importers = append(importers, Importer{Name: name, Import: importer})
+			added[name] = true
+		}
+	}
+	for _, name := range sortedKeys(CaptureImports) {
+		if !added[name] {
+			importers = append(importers, Importer{Name: name, Import: CaptureImports[name]})
+		}
+	}
+	return importers
 }
@@ -0,0 +1,495 @@
+package cmdgo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// An error returned from PromptSelect when a selection menu can't be displayed (ex: the input is not a terminal),
+// in which case the value is prompted for as text with PromptOnce.
+var ErrSelectUnavailable = errors.New("select unavailable")
+
+// Options that can be passed when prompting for one of several choices.
+type PromptSelectOptions struct {
+	// The text of each choice in the order they're displayed.
+	Choices []string
+	// The index of the choice which is initially selected, or -1 if none are.
+	Selected int
+	// The number of choices displayed at once. If zero all choices are displayed.
+	Height int
+	// The text which is returned instead of a choice when it's typed (ignoring case) and enter is pressed, ex: help!
+	Keywords []string
+}
+
+// Options that can be passed when prompting for any number of several choices.
+type PromptSelectManyOptions struct {
+	// The text of each choice in the order they're displayed.
+	Choices []string
+	// The indices of the choices which are initially selected.
+	Selected []int
+	// The minimum number of choices which must be selected.
+	Min int
+	// The maximum number of choices which can be selected, or zero if there is no maximum.
+	Max int
+	// The number of choices displayed at once. If zero all choices are displayed.
+	Height int
+	// The text which is returned as the only text instead of the checked choices when it's typed (ignoring case)
+	// and enter is pressed, ex: help!
+	Keywords []string
+}
+
+// Returns the selection options for the choices where the choice with the value or text of current is selected.
+// The keywords can be typed in place of selecting a choice.
+func getPromptSelectOptions(choices PromptChoices, current string, keywords []string) PromptSelectOptions {
+	texts := choices.Texts()
+	selected := -1
+	if current != "" {
+		for i, text := range texts {
+			choice := choices[Normalize(text)]
+			if text == current || choice.Value == current {
+				selected = i
+				break
+			}
+		}
+	}
+	return PromptSelectOptions{
+		Choices:  texts,
+		Selected: selected,
+		Keywords: keywords,
+	}
+}
+
+// Returns the selection options for the choices where the choices with the value or text of any current values are selected.
+// The keywords can be typed in place of checking choices.
+func getPromptSelectManyOptions(choices PromptChoices, current []string, min int, max int, keywords []string) PromptSelectManyOptions {
+	texts := choices.Texts()
+	selected := []int{}
+	for _, value := range current {
+		for i, text := range texts {
+			choice := choices[Normalize(text)]
+			if text == value || choice.Value == value {
+				selected = append(selected, i)
+				break
+			}
+		}
+	}
+	return PromptSelectManyOptions{
+		Choices:  texts,
+		Selected: selected,
+		Min:      min,
+		Max:      max,
+		Keywords: keywords,
+	}
+}
+
+// Displays a selection menu on the terminal of the options. The arrow keys move the selection, typing filters
+// the choices, enter returns the selected choice (or the keyword typed), escape returns an empty string, and ctrl+c returns ErrQuit.
+// If the options input is not a terminal ErrSelectUnavailable is returned.
+func (opts *Options) promptSelectTerminal(prompt string, options PromptSelectOptions) (string, error) {
+	if opts.in == nil || opts.out == nil || !term.IsTerminal(int(opts.in.Fd())) {
+		return "", ErrSelectUnavailable
+	}
+
+	state, err := term.MakeRaw(int(opts.in.Fd()))
+	if err != nil {
+		return "", ErrSelectUnavailable
+	}
+	defer term.Restore(int(opts.in.Fd()), state)
+
+	return runSelect(opts.inReader, opts.out, prompt, options)
+}
+
+// Displays a selection menu on the terminal of the options where any number of choices can be checked. The arrow keys
+// move the cursor, space checks or unchecks the choice under it, typing filters the choices, enter returns the checked
+// choices if there are between Min and Max of them (or the keyword typed), escape returns the initially selected choices,
+// and ctrl+c returns ErrQuit. If the options input is not a terminal ErrSelectUnavailable is returned.
+func (opts *Options) promptSelectManyTerminal(prompt string, options PromptSelectManyOptions) ([]string, error) {
+	if opts.in == nil || opts.out == nil || !term.IsTerminal(int(opts.in.Fd())) {
+		return nil, ErrSelectUnavailable
+	}
+
+	state, err := term.MakeRaw(int(opts.in.Fd()))
+	if err != nil {
+		return nil, ErrSelectUnavailable
+	}
+	defer term.Restore(int(opts.in.Fd()), state)
+
+	return runSelectMany(opts.inReader, opts.out, prompt, options)
+}
+
+// Prompts for any number of the choices, with PromptSelectMany if possible and otherwise with PromptOnce where
+// the choices are separated by commas. The choices are converted to their values and the number of values must
+// be between min and max (if max is not zero). If nothing is given and there are current values nil is returned.
+// If the HelpPrompt is given and the property has help it's displayed and the choices are prompted for again.
+func (opts *Options) promptMany(getPrompt func(status PromptStatus) (string, error), prop *Property, choices PromptChoices, current []string, min int, max int, tries int) ([]string, error) {
+	status := PromptStatus{}
+	lastError := ErrNoPrompt
+
+	opts.prompts++
+
+	for i := 0; i <= tries; i++ {
+		status.PromptCount = i
+		prompt, err := getPrompt(status)
+		if err != nil {
+			return nil, err
+		}
+
+		texts, err := opts.promptManyInput(prompt, choices, current, min, max)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(texts) == 1 && opts.HelpPrompt != "" && strings.EqualFold(texts[0], opts.HelpPrompt) && prop.Help != "" {
+			opts.DisplayHelp(prop.Help, prop)
+
+			status.AfterHelp = true
+			prompt, err = getPrompt(status)
+			if err != nil {
+				return nil, err
+			}
+
+			texts, err = opts.promptManyInput(prompt, choices, current, min, max)
+			if err != nil {
+				return nil, err
+			}
+
+			status.AfterHelp = false
+		}
+
+		if texts == nil {
+			return nil, nil
+		}
+
+		values := make([]string, 0, len(texts))
+		for _, text := range texts {
+			value, err := choices.Convert(text)
+			if err != nil {
+				status.InvalidChoice++
+				lastError = err
+				break
+			}
+			values = append(values, value)
+		}
+		if len(values) < len(texts) {
+			continue
+		}
+
+		if len(values) < min || (max > 0 && len(values) > max) {
+			status.InvalidFormat++
+			lastError = ErrCountFailed
+			continue
+		}
+
+		return values, nil
+	}
+
+	return nil, lastError
+}
+
+// Prompts for the text of any number of choices with PromptSelectMany, or PromptOnce if a selection menu is unavailable.
+func (opts *Options) promptManyInput(prompt string, choices PromptChoices, current []string, min int, max int) ([]string, error) {
+	if opts.PromptSelectMany != nil {
+		keywords := []string{opts.HelpPrompt, opts.QuitPrompt, opts.DiscardPrompt, opts.BackPrompt}
+		texts, err := opts.PromptSelectMany(prompt, getPromptSelectManyOptions(choices, current, min, max, keywords))
+		if err == nil && len(texts) == 1 {
+			err = opts.keywordError(texts[0])
+		}
+		if err != ErrSelectUnavailable {
+			return texts, err
+		}
+	}
+
+	input, err := opts.PromptOnce(prompt, PromptOnceOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	texts := []string{}
+	for _, text := range strings.Split(input, ",") {
+		if text = strings.TrimSpace(text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 && len(current) > 0 {
+		return nil, nil
+	}
+	return texts, nil
+}
+
+// The state of a selection menu.
+type selectMenu struct {
+	prompt   string
+	choices  []string
+	filter   string
+	filtered []int
+	selected int
+	top      int
+	height   int
+	// Whether each choice is checked when any number of choices can be selected, otherwise nil.
+	checked []bool
+}
+
+// Returns a menu of the choices with the initially selected choice.
+func newSelectMenu(prompt string, options PromptSelectOptions) *selectMenu {
+	menu := &selectMenu{
+		prompt:  prompt,
+		choices: options.Choices,
+		height:  options.Height,
+	}
+	menu.update()
+	for i, choice := range menu.filtered {
+		if choice == options.Selected {
+			menu.selected = i
+		}
+	}
+	menu.move(0)
+	return menu
+}
+
+// Returns a menu of the choices where any number can be checked, with the initially selected choices checked.
+func newSelectManyMenu(prompt string, options PromptSelectManyOptions) *selectMenu {
+	menu := &selectMenu{
+		prompt:  prompt,
+		choices: options.Choices,
+		height:  options.Height,
+		checked: make([]bool, len(options.Choices)),
+	}
+	for _, selected := range options.Selected {
+		if selected >= 0 && selected < len(menu.checked) {
+			menu.checked[selected] = true
+		}
+	}
+	menu.update()
+	return menu
+}
+
+// Filters the choices to the ones which contain the filter, ignoring case and non-alphanumeric characters.
+func (menu *selectMenu) update() {
+	filter := Normalize(menu.filter)
+	menu.filtered = menu.filtered[:0]
+	for i, choice := range menu.choices {
+		if strings.Contains(Normalize(choice), filter) {
+			menu.filtered = append(menu.filtered, i)
+		}
+	}
+	menu.selected = 0
+	menu.top = 0
+}
+
+// Moves the selection by the given amount, keeping it within the filtered choices.
+func (menu *selectMenu) move(amount int) {
+	menu.selected += amount
+	if menu.selected < 0 {
+		menu.selected = 0
+	}
+	if menu.selected >= len(menu.filtered) {
+		menu.selected = len(menu.filtered) - 1
+	}
+	if menu.height > 0 {
+		if menu.selected < menu.top {
+			menu.top = menu.selected
+		}
+		if menu.selected >= menu.top+menu.height {
+			menu.top = menu.selected - menu.height + 1
+		}
+	}
+}
+
+// Returns the keyword the filter is (ignoring case), or an empty string if it's not one of the keywords.
+func (menu *selectMenu) keyword(keywords []string) string {
+	for _, keyword := range keywords {
+		if keyword != "" && strings.EqualFold(menu.filter, keyword) {
+			return keyword
+		}
+	}
+	return ""
+}
+
+// Returns the selected choice, or an empty string if no choices match the filter.
+func (menu *selectMenu) value() string {
+	if menu.selected < 0 || menu.selected >= len(menu.filtered) {
+		return ""
+	}
+	return menu.choices[menu.filtered[menu.selected]]
+}
+
+// Checks or unchecks the selected choice. A choice is not checked if max choices are already checked.
+func (menu *selectMenu) toggle(max int) {
+	if menu.selected < 0 || menu.selected >= len(menu.filtered) {
+		return
+	}
+	choice := menu.filtered[menu.selected]
+	if !menu.checked[choice] && max > 0 && len(menu.values()) >= max {
+		return
+	}
+	menu.checked[choice] = !menu.checked[choice]
+}
+
+// Returns the checked choices in the order they're displayed.
+func (menu *selectMenu) values() []string {
+	values := []string{}
+	for i, checked := range menu.checked {
+		if checked {
+			values = append(values, menu.choices[i])
+		}
+	}
+	return values
+}
+
+// Draws the menu over the previously drawn menu, leaving the cursor at the end of the prompt.
+func (menu *selectMenu) render(out io.Writer) {
+	text := strings.Builder{}
+	text.WriteString("\r\x1b[J")
+	text.WriteString(menu.prompt)
+	text.WriteString(menu.filter)
+
+	end := len(menu.filtered)
+	if menu.height > 0 && menu.top+menu.height < end {
+		end = menu.top + menu.height
+	}
+	lines := 0
+	for i := menu.top; i < end; i++ {
+		marker := "  "
+		if i == menu.selected {
+			marker = "> "
+		}
+		if menu.checked != nil {
+			if menu.checked[menu.filtered[i]] {
+				marker += "[x] "
+			} else {
+				marker += "[ ] "
+			}
+		}
+		text.WriteString("\r\n" + marker + menu.choices[menu.filtered[i]])
+		lines++
+	}
+	if lines > 0 {
+		fmt.Fprintf(&text, "\x1b[%dA\r", lines)
+		if column := len(menu.prompt) + len(menu.filter); column > 0 {
+			fmt.Fprintf(&text, "\x1b[%dC", column)
+		}
+	}
+	io.WriteString(out, text.String())
+}
+
+// Draws the prompt with the selected value in place of the menu.
+func (menu *selectMenu) finish(out io.Writer, value string) {
+	io.WriteString(out, "\r\x1b[J"+menu.prompt+value+"\r\n")
+}
+
+// Handles the keys which move the selection or change the filter, returning whether the key was handled.
+func (menu *selectMenu) handle(key terminalKey, typed rune) bool {
+	switch key {
+	case terminalKeyUp:
+		menu.move(-1)
+	case terminalKeyDown:
+		menu.move(1)
+	case terminalKeyHome:
+		menu.move(-len(menu.filtered))
+	case terminalKeyEnd:
+		menu.move(len(menu.filtered))
+	case terminalKeyBackspace:
+		if menu.filter != "" {
+			runes := []rune(menu.filter)
+			menu.filter = string(runes[:len(runes)-1])
+			menu.update()
+		}
+	case terminalKeyRune:
+		menu.filter += string(typed)
+		menu.update()
+	default:
+		return false
+	}
+	return true
+}
+
+// Runs a selection menu which reads keys from the input and draws to the output until a choice is made.
+func runSelect(in *bufio.Reader, out io.Writer, prompt string, options PromptSelectOptions) (string, error) {
+	menu := newSelectMenu(prompt, options)
+	menu.render(out)
+
+	for {
+		key, typed, err := readTerminalKey(in)
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case terminalKeyEnter:
+			if keyword := menu.keyword(options.Keywords); keyword != "" {
+				menu.finish(out, menu.filter)
+				return keyword, nil
+			}
+			value := menu.value()
+			if value == "" {
+				continue
+			}
+			menu.finish(out, value)
+			return value, nil
+		case terminalKeyQuit:
+			menu.finish(out, "")
+			return "", ErrQuit
+		case terminalKeyEscape:
+			menu.finish(out, "")
+			return "", nil
+		default:
+			menu.handle(key, typed)
+		}
+
+		menu.render(out)
+	}
+}
+
+// Runs a selection menu where any number of choices can be checked which reads keys from the input
+// and draws to the output until the checked choices are confirmed.
+func runSelectMany(in *bufio.Reader, out io.Writer, prompt string, options PromptSelectManyOptions) ([]string, error) {
+	menu := newSelectManyMenu(prompt, options)
+	menu.render(out)
+
+	for {
+		key, typed, err := readTerminalKey(in)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case key == terminalKeyEnter:
+			if keyword := menu.keyword(options.Keywords); keyword != "" {
+				menu.finish(out, menu.filter)
+				return []string{keyword}, nil
+			}
+			values := menu.values()
+			if len(values) < options.Min || (options.Max > 0 && len(values) > options.Max) {
+				continue
+			}
+			menu.finish(out, strings.Join(values, ", "))
+			return values, nil
+		case key == terminalKeyQuit:
+			menu.finish(out, "")
+			return nil, ErrQuit
+		case key == terminalKeyEscape:
+			selected := append([]int{}, options.Selected...)
+			sort.Ints(selected)
+			values := []string{}
+			for _, i := range selected {
+				if i >= 0 && i < len(options.Choices) {
+					values = append(values, options.Choices[i])
+				}
+			}
+			menu.finish(out, strings.Join(values, ", "))
+			return values, nil
+		case key == terminalKeyRune && typed == ' ':
+			menu.toggle(options.Max)
+		default:
+			menu.handle(key, typed)
+		}
+
+		menu.render(out)
+	}
+}
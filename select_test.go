@@ -0,0 +1,345 @@
+package cmdgo
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunSelect(t *testing.T) {
+	choices := []string{"apple", "banana", "cherry"}
+
+	tests := []struct {
+		name          string
+		keys          string
+		selected      int
+		expected      string
+		expectedError error
+	}{
+		{name: "enter", keys: "\r", selected: -1, expected: "apple"},
+		{name: "preselected", keys: "\r", selected: 1, expected: "banana"},
+		{name: "down", keys: "\x1b[B\x1b[B\r", selected: -1, expected: "cherry"},
+		{name: "down past end", keys: "\x1b[B\x1b[B\x1b[B\r", selected: -1, expected: "cherry"},
+		{name: "up", keys: "\x1b[A\r", selected: 2, expected: "banana"},
+		{name: "filter", keys: "er\r", selected: -1, expected: "cherry"},
+		{name: "filter backspace", keys: "ch\x7f\x7fan\r", selected: -1, expected: "banana"},
+		{name: "filter no match", keys: "x\r\x7f\r", selected: -1, expected: "apple"},
+		{name: "escape", keys: "\x1b", selected: 1, expected: ""},
+		{name: "quit", keys: "\x03", selected: 1, expectedError: ErrQuit},
+		{name: "end of input", keys: "", selected: 1, expectedError: io.EOF},
+		{name: "keyword", keys: "HELP!\r", selected: 1, expected: "help!"},
+		{name: "keyword edited", keys: "quit\x7f\x7f\x7f\x7fdiscard!\r", selected: -1, expected: "discard!"},
+		{name: "keyword partial", keys: "help\r", selected: -1, expectedError: io.EOF},
+	}
+
+	for _, test := range tests {
+		out := strings.Builder{}
+		in := bufio.NewReader(strings.NewReader(test.keys))
+		actual, err := runSelect(in, &out, "Fruit: ", PromptSelectOptions{
+			Choices:  choices,
+			Selected: test.selected,
+			Keywords: []string{"help!", "discard!"},
+		})
+
+		if err != test.expectedError {
+			t.Errorf("Test [%s] expected error %v but got %v", test.name, test.expectedError, err)
+		} else if actual != test.expected {
+			t.Errorf("Test [%s] expected %q but got %q", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestPromptSelect(t *testing.T) {
+	type SelectCommand struct {
+		Size string `options:"small:s,medium:m,large:l"`
+	}
+
+	selects := 0
+	opts := NewOptions()
+	opts.ForcePrompt = true
+	opts.PromptSelect = func(prompt string, options PromptSelectOptions) (string, error) {
+		selects++
+		expected := []string{"large", "medium", "small"}
+		if !equalsJson(options.Choices, expected) {
+			t.Errorf("Expected choices %v but got %v", expected, options.Choices)
+		}
+		if options.Selected != 1 {
+			t.Errorf("Expected medium to be selected but got %d", options.Selected)
+		}
+		return "large", nil
+	}
+	opts.PromptOnce = func(prompt string, options PromptOnceOptions) (string, error) {
+		t.Errorf("Unexpected text prompt %s", prompt)
+		return "", nil
+	}
+
+	actual := SelectCommand{Size: "m"}
+	inst := GetInstance(&actual)
+	err := inst.Capture(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.Size != "l" || selects != 1 {
+		t.Errorf("Expected size l from one select but got %s from %d", actual.Size, selects)
+	}
+
+	opts.PromptSelect = func(prompt string, options PromptSelectOptions) (string, error) {
+		return "", ErrSelectUnavailable
+	}
+	opts.PromptOnce = func(prompt string, options PromptOnceOptions) (string, error) {
+		return "sm", nil
+	}
+
+	err = inst.Capture(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.Size != "s" {
+		t.Errorf("Expected size s from the text prompt but got %s", actual.Size)
+	}
+}
+
+func TestRunSelectMany(t *testing.T) {
+	choices := []string{"apple", "banana", "cherry"}
+
+	tests := []struct {
+		name          string
+		keys          string
+		selected      []int
+		min           int
+		max           int
+		expected      []string
+		expectedError error
+	}{
+		{name: "none", keys: "\r", expected: []string{}},
+		{name: "preselected", keys: "\r", selected: []int{2, 0}, expected: []string{"apple", "cherry"}},
+		{name: "toggle", keys: " \x1b[B\x1b[B \r", expected: []string{"apple", "cherry"}},
+		{name: "untoggle", keys: " \r", selected: []int{0, 1}, expected: []string{"banana"}},
+		{name: "filter", keys: "ban \r", expected: []string{"banana"}},
+		{name: "min", keys: "\r \r", min: 1, expected: []string{"apple"}},
+		{name: "max", keys: " \x1b[B \x1b[B \r", max: 2, expected: []string{"apple", "banana"}},
+		{name: "escape", keys: " \x1b", selected: []int{1}, expected: []string{"banana"}},
+		{name: "quit", keys: "\x03", expectedError: ErrQuit},
+		{name: "keyword", keys: " Back!\r", min: 2, expected: []string{"back!"}},
+	}
+
+	for _, test := range tests {
+		out := strings.Builder{}
+		in := bufio.NewReader(strings.NewReader(test.keys))
+		actual, err := runSelectMany(in, &out, "Fruit: ", PromptSelectManyOptions{
+			Choices:  choices,
+			Selected: test.selected,
+			Min:      test.min,
+			Max:      test.max,
+			Keywords: []string{"back!"},
+		})
+
+		if err != test.expectedError {
+			t.Errorf("Test [%s] expected error %v but got %v", test.name, test.expectedError, err)
+		} else if err == nil && !equalsJson(actual, test.expected) {
+			t.Errorf("Test [%s] expected %v but got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestPromptSelectMany(t *testing.T) {
+	type SelectManyCommand struct {
+		Sizes []string `options:"small:s,medium:m,large:l" min:"1" max:"2"`
+	}
+
+	opts := NewOptions()
+	opts.ForcePrompt = true
+	opts.PromptSelectMany = func(prompt string, options PromptSelectManyOptions) ([]string, error) {
+		if !equalsJson(options.Selected, []int{1}) || options.Min != 1 || options.Max != 2 {
+			t.Errorf("Unexpected select options %+v", options)
+		}
+		return []string{"large", "small"}, nil
+	}
+	opts.PromptOnce = func(prompt string, options PromptOnceOptions) (string, error) {
+		t.Errorf("Unexpected text prompt %s", prompt)
+		return "", nil
+	}
+
+	actual := SelectManyCommand{Sizes: []string{"m"}}
+	inst := GetInstance(&actual)
+	err := inst.Capture(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalsJson(actual.Sizes, []string{"l", "s"}) {
+		t.Errorf("Expected sizes [l s] from the select but got %v", actual.Sizes)
+	}
+
+	inputs := []string{"small, huge", "s, m, l", "me,la"}
+	opts.PromptSelectMany = func(prompt string, options PromptSelectManyOptions) ([]string, error) {
+		return nil, ErrSelectUnavailable
+	}
+	opts.PromptOnce = func(prompt string, options PromptOnceOptions) (string, error) {
+		input := inputs[0]
+		inputs = inputs[1:]
+		return input, nil
+	}
+
+	err = inst.Capture(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalsJson(actual.Sizes, []string{"m", "l"}) || len(inputs) != 0 {
+		t.Errorf("Expected sizes [m l] from the third text prompt but got %v", actual.Sizes)
+	}
+
+	actual.Sizes = nil
+	opts = NewOptions().WithArgs([]string{"--sizes", "large"})
+	opts.ForcePrompt = true
+	opts.PromptSelectMany = func(prompt string, options PromptSelectManyOptions) ([]string, error) {
+		t.Errorf("Unexpected select %s", prompt)
+		return nil, nil
+	}
+
+	err = inst.Capture(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalsJson(actual.Sizes, []string{"l"}) {
+		t.Errorf("Expected sizes [l] from the args but got %v", actual.Sizes)
+	}
+}
+
+func TestPromptSelectKeywords(t *testing.T) {
+	type SelectCommand struct {
+		Size string `options:"small,large" help:"How big"`
+	}
+
+	tests := []struct {
+		name          string
+		selected      string
+		expected      string
+		expectedHelp  bool
+		expectedError error
+	}{
+		{name: "help", selected: "help!", expected: "large", expectedHelp: true},
+		{name: "quit", selected: "quit!", expectedError: ErrQuit},
+		{name: "discard", selected: "discard!", expectedError: ErrDiscard},
+	}
+
+	for _, test := range tests {
+		helped := false
+		opts := NewOptions()
+		opts.ForcePrompt = true
+		opts.PromptSelect = func(prompt string, options PromptSelectOptions) (string, error) {
+			if !equalsJson(options.Keywords, []string{opts.HelpPrompt, opts.QuitPrompt, opts.DiscardPrompt, opts.BackPrompt}) {
+				t.Errorf("Test [%s] unexpected keywords %v", test.name, options.Keywords)
+			}
+			return test.selected, nil
+		}
+		opts.PromptOnce = func(prompt string, options PromptOnceOptions) (string, error) {
+			return "large", nil
+		}
+		opts.DisplayHelp = func(help string, prop *Property) {
+			helped = true
+		}
+
+		actual := SelectCommand{}
+		inst := GetInstance(&actual)
+		err := inst.Capture(opts)
+
+		if err != test.expectedError {
+			t.Errorf("Test [%s] expected error %v but got %v", test.name, test.expectedError, err)
+		} else if actual.Size != test.expected || helped != test.expectedHelp {
+			t.Errorf("Test [%s] expected %q (help %v) but got %q (help %v)", test.name, test.expected, test.expectedHelp, actual.Size, helped)
+		}
+	}
+}
+
+func TestPromptSelectManyKeywords(t *testing.T) {
+	type SelectManyCommand struct {
+		Sizes []string `options:"small,large" help:"How big"`
+	}
+
+	tests := []struct {
+		name          string
+		selected      []string
+		expected      []string
+		expectedHelp  bool
+		expectedError error
+	}{
+		{name: "help", selected: []string{"help!"}, expected: []string{"large"}, expectedHelp: true},
+		{name: "help ignoring case", selected: []string{"HELP!"}, expected: []string{"large"}, expectedHelp: true},
+		{name: "quit", selected: []string{"quit!"}, expectedError: ErrQuit},
+		{name: "discard", selected: []string{"discard!"}, expectedError: ErrDiscard},
+	}
+
+	for _, test := range tests {
+		helped := false
+		opts := NewOptions()
+		opts.ForcePrompt = true
+		opts.PromptSelectMany = func(prompt string, options PromptSelectManyOptions) ([]string, error) {
+			if !equalsJson(options.Keywords, []string{opts.HelpPrompt, opts.QuitPrompt, opts.DiscardPrompt, opts.BackPrompt}) {
+				t.Errorf("Test [%s] unexpected keywords %v", test.name, options.Keywords)
+			}
+			if helped {
+				return []string{"large"}, nil
+			}
+			return test.selected, nil
+		}
+		opts.DisplayHelp = func(help string, prop *Property) {
+			helped = true
+		}
+
+		actual := SelectManyCommand{}
+		inst := GetInstance(&actual)
+		err := inst.Capture(opts)
+
+		if err != test.expectedError {
+			t.Errorf("Test [%s] expected error %v but got %v", test.name, test.expectedError, err)
+		} else if !equalsJson(actual.Sizes, test.expected) || helped != test.expectedHelp {
+			t.Errorf("Test [%s] expected %v (help %v) but got %v (help %v)", test.name, test.expected, test.expectedHelp, actual.Sizes, helped)
+		}
+	}
+}
+
+func TestSelectBack(t *testing.T) {
+	type SelectBackCommand struct {
+		Name  string
+		Size  string   `options:"small,large"`
+		Sizes []string `options:"small,large"`
+	}
+
+	names := []string{"Bob", "Rob"}
+	selects := []string{"back!", "large", "small"}
+	selectMany := [][]string{{"back!"}, {"large", "small"}}
+
+	opts := NewOptions()
+	opts.ForcePrompt = true
+	opts.PromptOnce = func(prompt string, options PromptOnceOptions) (string, error) {
+		name := names[0]
+		names = names[1:]
+		return name, nil
+	}
+	opts.PromptSelect = func(prompt string, options PromptSelectOptions) (string, error) {
+		if !equalsJson(options.Keywords, []string{opts.HelpPrompt, opts.QuitPrompt, opts.DiscardPrompt, opts.BackPrompt}) {
+			t.Errorf("Unexpected keywords %v", options.Keywords)
+		}
+		selected := selects[0]
+		selects = selects[1:]
+		return selected, nil
+	}
+	opts.PromptSelectMany = func(prompt string, options PromptSelectManyOptions) ([]string, error) {
+		selected := selectMany[0]
+		selectMany = selectMany[1:]
+		return selected, nil
+	}
+
+	actual := SelectBackCommand{}
+	inst := GetInstance(&actual)
+	err := inst.Capture(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := SelectBackCommand{Name: "Rob", Size: "small", Sizes: []string{"large", "small"}}
+	if !equalsJson(actual, expected) || len(names)+len(selects)+len(selectMany) != 0 {
+		t.Errorf("Expected %s but got %s", toJson(expected), toJson(actual))
+	}
+}
@@ -0,0 +1,105 @@
+package cmdgo
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A command which starts an interactive shell over the registry executing it.
+// ex: myprogram shell
+type ShellCommand struct{}
+
+var _ Executable = &ShellCommand{}
+
+func (cmd *ShellCommand) Execute(opts *Options) error {
+	registry := opts.Registry()
+	if registry == nil {
+		return ErrNoCommand
+	}
+	return registry.Shell(opts)
+}
+
+// Adds the "shell" command to the registry which starts an interactive shell. See Registry.Shell.
+func (r *Registry) AddShell() {
+	r.Add(Entry{
+		Name:      "shell",
+		HelpShort: "Starts an interactive shell to run commands.",
+		HelpLong:  "Starts an interactive shell to run commands. Enter help for a list of commands and exit to stop.",
+		Command:   ShellCommand{},
+	})
+}
+
+// Starts an interactive shell which prompts for a line, splits it into arguments like a shell would,
+// and executes the command. The options (and its Values) are shared between commands. The shell supports:
+//   - help [command]: displays help for all commands or the given command.
+//   - history: lists the lines entered so far.
+//   - !! or !n: executes the last line or the nth line in the history.
+//   - exit: stops the shell, as does the QuitPrompt or the end of input.
+//
+// Errors returned from commands are displayed with DisplayCommandError and the shell continues. Entering the QuitPrompt
+// while a command is prompting stops that command and returns to the shell.
+func (r Registry) Shell(opts *Options) error {
+	history := make([]string, 0)
+
+	for {
+		line, err := opts.PromptOnce(opts.ShellPrompt, PromptOnceOptions{ReturnEOF: true, History: "shell"})
+		if err == ErrQuit || err == io.EOF {
+			return nil
+		}
+		if err == ErrDiscard || err == ErrBack {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "!") {
+			index := len(history)
+			if line != "!!" {
+				index, err = strconv.Atoi(line[1:])
+			}
+			if err != nil || index < 1 || index > len(history) {
+				opts.Printf("%s: event not found\n", line)
+				continue
+			}
+			line = history[index-1]
+			opts.Printf("%s\n", line)
+		}
+
+		args, err := SplitArgs(line)
+		if err != nil {
+			opts.Printf("%v\n", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		history = append(history, line)
+
+		switch {
+		case strings.EqualFold(args[0], "exit"):
+			return nil
+		case strings.EqualFold(args[0], "help"), args[0] == opts.HelpPrompt:
+			help := ""
+			if len(args) > 1 {
+				help = args[1]
+			}
+			err = DisplayHelp(opts, r, help)
+		case strings.EqualFold(args[0], "history"):
+			for i, previous := range history {
+				opts.Printf("%4d  %s\n", i+1, previous)
+			}
+		default:
+			opts.WithArgs(args)
+			_, err = r.ExecuteReturn(opts)
+		}
+
+		if err != nil && err != ErrQuit {
+			DisplayCommandError(opts, r, err)
+		}
+	}
+}
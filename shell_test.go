@@ -0,0 +1,106 @@
+package cmdgo
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+type ShellCounter struct {
+	Add int
+}
+
+func (cmd *ShellCounter) Execute(opts *Options) error {
+	total, _ := opts.Values["total"].(int)
+	opts.Values["total"] = total + cmd.Add
+	return nil
+}
+
+func TestShell(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected int
+		output   []string
+	}{
+		{
+			name:     "exit",
+			lines:    []string{"counter --add 2", "counter --add '3'", "exit", "counter --add 4"},
+			expected: 5,
+		},
+		{
+			name:     "end of input",
+			lines:    []string{"counter --add 2", ""},
+			expected: 2,
+		},
+		{
+			name:     "quit",
+			lines:    []string{"counter --add 1", "quit!"},
+			expected: 1,
+		},
+		{
+			name:     "history",
+			lines:    []string{"counter --add 2", "counter --add 3", "!!", "!1", "history", "!9", "exit"},
+			expected: 10,
+			output:   []string{"   1  counter --add 2\n   2  counter --add 3\n   3  counter --add 3\n   4  counter --add 2\n   5  history\n", "!9: event not found\n"},
+		},
+		{
+			name:     "errors continue",
+			lines:    []string{"nope", "counter --add x", "counter --add 'oops", "counter --add 6", "exit"},
+			expected: 6,
+			output:   []string{"nope is not a valid command. Valid commands:\ncounter  Adds to the total\n", "unclosed quote\n"},
+		},
+		{
+			name:     "help",
+			lines:    []string{"help", "exit"},
+			expected: 0,
+			output:   []string{"counter  Adds to the total\n"},
+		},
+	}
+
+	for _, test := range tests {
+		registry := CreateRegistry([]Entry{
+			{Name: "counter", HelpShort: "Adds to the total", Command: ShellCounter{}},
+		})
+
+		out, err := os.CreateTemp("", "shell")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(out.Name())
+
+		opts := NewOptions()
+		opts.WithFiles(nil, out)
+		opts.Values["total"] = 0
+		opts.PromptOnce = func(prompt string, options PromptOnceOptions) (string, error) {
+			if prompt != opts.ShellPrompt {
+				return "", fmt.Errorf("Unexpected prompt '%s'", prompt)
+			}
+			if len(test.lines) == 0 || test.lines[0] == "" {
+				return "", io.EOF
+			}
+			line := test.lines[0]
+			test.lines = test.lines[1:]
+			if line == opts.QuitPrompt {
+				return line, ErrQuit
+			}
+			return line, nil
+		}
+
+		err = registry.Shell(opts)
+		printed, _ := os.ReadFile(out.Name())
+
+		if err != nil {
+			t.Errorf("Test [%s] failed with error %v", test.name, err)
+		} else if opts.Values["total"] != test.expected {
+			t.Errorf("Test [%s] expected total %d but got %v", test.name, test.expected, opts.Values["total"])
+		}
+		for _, output := range test.output {
+			if !strings.Contains(string(printed), output) {
+				t.Errorf("Test [%s] expected output %q, got %q", test.name, output, printed)
+			}
+		}
+	}
+}
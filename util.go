@@ -7,35 +7,79 @@ import (
 	"os/signal"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
+	"unicode"
 )
 
-var normalizer, _ = regexp.Compile("[^a-zA-Z0-9]")
-
 // Normalizes the string which removes all non-letters and numbers and converts it to lowercase.
 func Normalize(x string) string {
-	return strings.ToLower(string(normalizer.ReplaceAll([]byte(x), []byte(""))))
+	normal := make([]byte, 0, len(x))
+	for i := 0; i < len(x); i++ {
+		c := x[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			normal = append(normal, c)
+		case c >= 'A' && c <= 'Z':
+			normal = append(normal, c+('a'-'A'))
+		}
+	}
+	return string(normal)
+}
+
+// The argument which ends argument name parsing, all arguments after it are values.
+const ArgTerminator = "--"
+
+var numberArg, _ = regexp.Compile(`^[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$`)
+
+// Returns whether the argument is an argument name with the given prefix. The argument
+// terminator, the prefix alone, and numbers (ex: -5 when the prefix is -) are not argument names.
+func IsArgName(arg string, argPrefix string) bool {
+	return arg != ArgTerminator && len(arg) > len(argPrefix) && strings.EqualFold(arg[:len(argPrefix)], argPrefix) && !isNumberArg(arg)
+}
+
+// Returns whether the argument is a number, ex: -5, +1.5, -.5e3
+func isNumberArg(arg string) bool {
+	unsigned := strings.TrimLeft(arg, "-+")
+	if unsigned == "" || !(unsigned[0] == '.' || (unsigned[0] >= '0' && unsigned[0] <= '9')) {
+		return false
+	}
+	return numberArg.MatchString(arg)
+}
+
+// Splits the argument name into its name and value if it's in the form name=value.
+func splitArgValue(arg string) (name string, value string, hasValue bool) {
+	if i := strings.IndexByte(arg, '='); i != -1 {
+		return arg[:i], arg[i+1:], true
+	}
+	return arg, "", false
 }
 
 // Finds the first argument in args that is named argPrefix+name and returns the value while removing the name & value from args.
+// The value can be the argument after the name or follow an equals sign (ex: --name=value). An argument after the name
+// which is an argument name is not a value. Arguments after the ArgTerminator are not searched.
 func GetArg(name string, defaultValue string, args *[]string, argPrefix string, flag bool) string {
 	normal := Normalize(name)
 	erase := 0
 	index := 0
 	value := defaultValue
-	lowerPrefix := strings.ToLower(argPrefix)
 	argsNow := *args
 	for index < len(argsNow) {
 		arg := argsNow[index]
-		if strings.HasPrefix(strings.ToLower(arg), lowerPrefix) {
-			key := Normalize(arg[len(argPrefix):])
-			if key == normal {
+		if arg == ArgTerminator {
+			break
+		}
+		if IsArgName(arg, argPrefix) {
+			key, inline, hasInline := splitArgValue(arg[len(argPrefix):])
+			if Normalize(key) == normal {
 				erase = 1
-				if index+1 < len(argsNow) {
+				if hasInline {
+					value = inline
+				} else if index+1 < len(argsNow) {
 					value = argsNow[index+1]
-					if strings.HasPrefix(strings.ToLower(value), lowerPrefix) {
+					if IsArgName(value, argPrefix) || value == ArgTerminator {
 						value = defaultValue
 					} else {
 						erase = 2
@@ -60,6 +104,326 @@ func GetArg(name string, defaultValue string, args *[]string, argPrefix string,
 	return value
 }
 
+// An index of arguments by their normalized names, built in a single pass over the arguments. Getting an argument
+// from the index has the same result as GetArg without the linear search and re-slicing of the arguments.
+type argIndex struct {
+	prefix string
+	args   []string
+	used   []bool
+	names  map[string][]int
+	// The position of the argument name last taken or counted, or -1 if none have been.
+	last int
+	// The position of each argument in the original arguments, or nil if they're not known.
+	origins []int
+}
+
+// Creates an index of the given arguments where argument names have the given prefix.
+func newArgIndex(args []string, argPrefix string) *argIndex {
+	index := &argIndex{
+		prefix: argPrefix,
+		args:   args,
+		used:   make([]bool, len(args)),
+		names:  make(map[string][]int),
+		last:   -1,
+	}
+
+	for i, arg := range args {
+		if arg == ArgTerminator {
+			break
+		}
+		if IsArgName(arg, argPrefix) {
+			name, _, _ := splitArgValue(arg[len(argPrefix):])
+			key := Normalize(name)
+			index.names[key] = append(index.names[key], i)
+		}
+	}
+
+	return index
+}
+
+// Returns the value of the first unused argument named argPrefix+name and marks the name & value as used.
+func (index *argIndex) get(argPrefix string, name string, defaultValue string, flag bool) string {
+	position, prefix := index.next(argPrefix, name)
+	if position == -1 {
+		return defaultValue
+	}
+	return index.take(position, prefix, defaultValue, flag)
+}
+
+// Returns the value of the first unused argument named argPrefix+name and whether there was one, marking the name & value as used.
+// The value is empty if the argument was given without one, ex: --name=
+func (index *argIndex) lookup(argPrefix string, name string, flag bool) (string, bool) {
+	position, prefix := index.next(argPrefix, name)
+	if position == -1 {
+		return "", false
+	}
+	return index.take(position, prefix, "", flag), true
+}
+
+// Marks all unused arguments named argPrefix+name as used and returns how many there were. An argument
+// with a value after an equals sign adds that value to the count instead of one, ex: --verbose=3
+func (index *argIndex) count(argPrefix string, name string) (int, error) {
+	count := 0
+	for {
+		position, prefix := index.next(argPrefix, name)
+		if position == -1 {
+			return count, nil
+		}
+		if count == 0 {
+			index.last = position
+		}
+		index.used[position] = true
+		_, inline, hasInline := splitArgValue(index.args[position][len(prefix):])
+		if !hasInline {
+			count++
+			continue
+		}
+		value, err := strconv.Atoi(inline)
+		if err != nil {
+			return count, err
+		}
+		count += value
+	}
+}
+
+// Returns the position of the first unused argument named argPrefix+name and the prefix
+// of the argument, or -1 if there are none.
+func (index *argIndex) next(argPrefix string, name string) (int, string) {
+	full := argPrefix + name
+	if len(full) < len(index.prefix) || !strings.EqualFold(full[:len(index.prefix)], index.prefix) {
+		return index.scan(argPrefix, name), argPrefix
+	}
+
+	key := Normalize(full[len(index.prefix):])
+	positions := index.names[key]
+	for len(positions) > 0 && index.used[positions[0]] {
+		positions = positions[1:]
+	}
+	if len(positions) == 0 {
+		delete(index.names, key)
+		return -1, index.prefix
+	}
+	index.names[key] = positions[1:]
+
+	return positions[0], index.prefix
+}
+
+// Searches the unused arguments for argPrefix+name when argPrefix does not start with the prefix of the index.
+func (index *argIndex) scan(argPrefix string, name string) int {
+	normal := Normalize(name)
+	for i, arg := range index.args {
+		if arg == ArgTerminator {
+			break
+		}
+		if index.used[i] || !IsArgName(arg, argPrefix) {
+			continue
+		}
+		key, _, _ := splitArgValue(arg[len(argPrefix):])
+		if Normalize(key) == normal {
+			return i
+		}
+	}
+	return -1
+}
+
+// Marks the argument name at the given position as used and returns its value, marking the value as used as well.
+func (index *argIndex) take(position int, argPrefix string, defaultValue string, flag bool) string {
+	index.used[position] = true
+	index.last = position
+
+	value := defaultValue
+	_, inline, hasInline := splitArgValue(index.args[position][len(argPrefix):])
+	if hasInline {
+		value = inline
+	} else if next := position + 1; next < len(index.args) && !index.used[next] {
+		nextArg := index.args[next]
+		if !IsArgName(nextArg, index.prefix) && nextArg != ArgTerminator {
+			value = nextArg
+			index.used[next] = true
+		}
+	}
+	if flag && value == defaultValue {
+		value = "true"
+	}
+
+	return value
+}
+
+// Returns the position of the argument at the given position in the original arguments, or -1 if it's not known.
+func (index *argIndex) origin(position int) int {
+	if position < 0 || position >= len(index.origins) {
+		return -1
+	}
+	return index.origins[position]
+}
+
+// Returns the position of each of the arguments in the original arguments they were taken from. Arguments
+// are only removed from the original arguments so each is matched to the next original argument that equals it,
+// an argument which is not found is given -1.
+func getArgOrigins(args []string, original []string) []int {
+	origins := make([]int, len(args))
+	next := 0
+	for i, arg := range args {
+		origins[i] = -1
+		for j := next; j < len(original); j++ {
+			if original[j] == arg {
+				origins[i] = j
+				next = j + 1
+				break
+			}
+		}
+	}
+	return origins
+}
+
+// Returns the arguments which have not been used, in their original order.
+func (index *argIndex) remaining() []string {
+	remaining := make([]string, 0, len(index.args))
+	for i, arg := range index.args {
+		if !index.used[i] {
+			remaining = append(remaining, arg)
+		}
+	}
+	return remaining
+}
+
+// An error returned when splitting text into arguments and a quote is not closed.
+var ErrUnclosedQuote = errors.New("unclosed quote")
+
+// Splits the text into arguments the way a shell would. Arguments are separated by whitespace,
+// single quotes preserve the literal text, double quotes allow \" and \\ escapes, a backslash outside
+// of quotes escapes the next character, and a # at the start of an argument comments out the rest of the line.
+func SplitArgs(text string) ([]string, error) {
+	args := make([]string, 0)
+	current := strings.Builder{}
+	inArg := false
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			if i+1 < len(runes) {
+				i++
+				if runes[i] != '\n' {
+					current.WriteRune(runes[i])
+				}
+			}
+			inArg = true
+		case r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != '\'' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, ErrUnclosedQuote
+			}
+			current.WriteString(string(runes[i+1 : end]))
+			inArg = true
+			i = end
+		case r == '"':
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+					i++
+				}
+				current.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, ErrUnclosedQuote
+			}
+			inArg = true
+		case r == '#' && !inArg:
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// Returns the number of single character insertions, deletions, or substitutions needed to change a into b.
+func EditDistance(a string, b string) int {
+	ar, br := []rune(a), []rune(b)
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(br)]
+}
+
+// The maximum number of suggestions returned by Suggest.
+var SuggestMax = 3
+
+// Returns the candidates which are similar to the input (normalized), closest first. A candidate is similar
+// if it starts with the input or if the edit distance is at most a quarter of the input length (rounded down) plus one.
+func Suggest(input string, candidates []string) []string {
+	type suggestion struct {
+		text     string
+		distance int
+	}
+
+	normal := Normalize(input)
+	maxDistance := len(normal)/4 + 1
+	suggestions := make([]suggestion, 0)
+	seen := make(map[string]struct{})
+
+	for _, candidate := range candidates {
+		candidateNormal := Normalize(candidate)
+		if _, exists := seen[candidate]; exists || candidateNormal == "" || normal == "" {
+			continue
+		}
+		distance := EditDistance(normal, candidateNormal)
+		if strings.HasPrefix(candidateNormal, normal) || distance <= maxDistance {
+			suggestions = append(suggestions, suggestion{text: candidate, distance: distance})
+			seen[candidate] = struct{}{}
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+
+	texts := make([]string, 0, len(suggestions))
+	for i := 0; i < len(suggestions) && i < SuggestMax; i++ {
+		texts = append(texts, suggestions[i].text)
+	}
+	return texts
+}
+
 // Notifies the function when the exit signal is sent.
 func CaptureExitSignal(f func()) {
 	cSignal := make(chan os.Signal, 1)
@@ -211,6 +575,39 @@ func concreteType(typ reflect.Type) reflect.Type {
 	return typ
 }
 
+// Replaces each ${NAME} in the text with the value of the environment variable NAME.
+func expandEnvRefs(text string) string {
+	if !strings.Contains(text, "${") {
+		return text
+	}
+	out := strings.Builder{}
+	for {
+		start := strings.Index(text, "${")
+		if start == -1 {
+			break
+		}
+		end := strings.IndexByte(text[start+2:], '}')
+		if end == -1 {
+			break
+		}
+		out.WriteString(text[:start])
+		out.WriteString(os.Getenv(text[start+2 : start+2+end]))
+		text = text[start+2+end+1:]
+	}
+	out.WriteString(text)
+	return out.String()
+}
+
+// Returns whether the kind is a signed or unsigned integer.
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
 // Computes the non-pointer kind of the any value.
 func concreteKind(value any) reflect.Kind {
 	ref := reflectValue(value)
@@ -240,6 +637,13 @@ func initialize(value reflect.Value) reflect.Value {
 	return value
 }
 
+// Returns a settable copy of the given value.
+func copyValue(value reflect.Value) reflect.Value {
+	copied := reflect.New(value.Type()).Elem()
+	copied.Set(value)
+	return copied
+}
+
 // Returns a pointer value to the given value.
 func pointerOf(value reflect.Value) reflect.Value {
 	ptr := reflect.New(value.Type())
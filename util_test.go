@@ -1,6 +1,10 @@
 package cmdgo
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
 
 func TestGetArg(t *testing.T) {
 	tests := []struct {
@@ -52,15 +56,201 @@ func TestGetArg(t *testing.T) {
 			flag:         false,
 			expected:     "xx",
 		},
+		{
+			name:         "a",
+			defaultValue: "",
+			args:         []string{"-a=-x=1"},
+			flag:         false,
+			expected:     "-x=1",
+		},
+		{
+			name:         "doit",
+			defaultValue: "",
+			args:         []string{"-doit=false"},
+			flag:         true,
+			expected:     "false",
+		},
+		{
+			name:         "a",
+			defaultValue: "",
+			args:         []string{"-a", "-5"},
+			flag:         false,
+			expected:     "-5",
+		},
+		{
+			name:         "a",
+			defaultValue: "",
+			args:         []string{"-a", "-.5e3"},
+			flag:         false,
+			expected:     "-.5e3",
+		},
+		{
+			name:         "a",
+			defaultValue: "",
+			args:         []string{"--", "-a", "hello"},
+			flag:         false,
+			expected:     "",
+		},
+		{
+			name:         "a",
+			defaultValue: "",
+			args:         []string{"-a", "--", "hello"},
+			flag:         false,
+			expected:     "",
+		},
 	}
 
 	for _, test := range tests {
+		indexed := newArgIndex(append([]string{}, test.args...), "-")
 		actual := GetArg(test.name, test.defaultValue, &test.args, "-", test.flag)
 		if actual != test.expected {
 			t.Errorf("Expected %s but got %s", test.expected, actual)
 		}
+		actualIndexed := indexed.get("-", test.name, test.defaultValue, test.flag)
+		if actualIndexed != test.expected {
+			t.Errorf("Expected indexed %s but got %s", test.expected, actualIndexed)
+		}
+		if remaining := indexed.remaining(); strings.Join(remaining, " ") != strings.Join(test.args, " ") {
+			t.Errorf("Expected indexed remaining %v but got %v", test.args, remaining)
+		}
+	}
+
+}
+
+func TestArgIndex(t *testing.T) {
+	args := []string{"--items-1-name", "a", "--items-2-name=b", "--items-1-count", "1", "--flag", "--tag", "x", "--tag", "y", "--", "--tag", "z"}
+	index := newArgIndex(args, "--")
+
+	gets := []struct {
+		prefix   string
+		name     string
+		flag     bool
+		expected string
+	}{
+		{prefix: "--items-2-", name: "name", expected: "b"},
+		{prefix: "--items-1-", name: "name", expected: "a"},
+		{prefix: "--items-1-", name: "name", expected: ""},
+		{prefix: "--", name: "items-1-count", expected: "1"},
+		{prefix: "--", name: "flag", flag: true, expected: "true"},
+		{prefix: "--", name: "tag", expected: "x"},
+		{prefix: "--", name: "tag", expected: "y"},
+		{prefix: "--", name: "tag", expected: ""},
+		{prefix: "-", name: "-missing", expected: ""},
+	}
+
+	for _, get := range gets {
+		actual := index.get(get.prefix, get.name, "", get.flag)
+		if actual != get.expected {
+			t.Errorf("Get %s%s expected %q but got %q", get.prefix, get.name, get.expected, actual)
+		}
+	}
+
+	expected := "-- --tag z"
+	if remaining := strings.Join(index.remaining(), " "); remaining != expected {
+		t.Errorf("Expected remaining %q but got %q", expected, remaining)
+	}
+}
+
+func benchmarkArgs(count int) (args []string, prefixes []string) {
+	args = make([]string, 0, count*4)
+	prefixes = make([]string, count)
+	for i := 1; i <= count; i++ {
+		prefixes[i-1] = fmt.Sprintf("--items-%d-", i)
+		args = append(args, prefixes[i-1]+"name", "name", prefixes[i-1]+"count", "1")
+	}
+	return
+}
+
+func BenchmarkGetArg(b *testing.B) {
+	for _, count := range []int{10, 100, 1000} {
+		source, prefixes := benchmarkArgs(count)
+		b.Run(fmt.Sprint(count), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				args := append([]string{}, source...)
+				for _, prefix := range prefixes {
+					GetArg("name", "", &args, prefix, false)
+					GetArg("count", "", &args, prefix, false)
+					GetArg("missing", "", &args, prefix, false)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkArgIndex(b *testing.B) {
+	for _, count := range []int{10, 100, 1000} {
+		source, prefixes := benchmarkArgs(count)
+		b.Run(fmt.Sprint(count), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				index := newArgIndex(source, "--")
+				for _, prefix := range prefixes {
+					index.get(prefix, "name", "", false)
+					index.get(prefix, "count", "", false)
+					index.get(prefix, "missing", "", false)
+				}
+			}
+		})
+	}
+}
+
+type benchmarkCommand struct {
+	Items []struct {
+		Name  string
+		Count int
+	}
+}
+
+func BenchmarkCaptureSlice(b *testing.B) {
+	for _, count := range []int{10, 100, 1000} {
+		source, _ := benchmarkArgs(count)
+		b.Run(fmt.Sprint(count), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				cmd := benchmarkCommand{}
+				err := Unmarshal(NewOptions().WithArgs(source), &cmd)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(cmd.Items) != count {
+					b.Fatalf("Expected %d items but got %d", count, len(cmd.Items))
+				}
+			}
+		})
+	}
+}
+
+func TestGetArgOrigins(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		original []string
+		expected []int
+	}{
+		{
+			name:     "unchanged",
+			args:     []string{"--a", "1"},
+			original: []string{"--a", "1"},
+			expected: []int{0, 1},
+		},
+		{
+			name:     "removed",
+			args:     []string{"--a", "1", "--b"},
+			original: []string{"cmd", "--json", "x.json", "--a", "1", "--help", "--b"},
+			expected: []int{3, 4, 6},
+		},
+		{
+			name:     "missing",
+			args:     []string{"--a", "--c", "1"},
+			original: []string{"--a", "--b", "1"},
+			expected: []int{0, -1, 2},
+		},
 	}
 
+	for _, test := range tests {
+		actual := getArgOrigins(test.args, test.original)
+		if !equalsJson(actual, test.expected) {
+			t.Errorf("Test [%s] expected %v but got %v", test.name, test.expected, actual)
+		}
+	}
 }
 
 func TestNormalize(t *testing.T) {
@@ -136,3 +326,111 @@ func TestIsDefaultValue(t *testing.T) {
 		}
 	}
 }
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+		err      error
+	}{
+		{
+			text:     "",
+			expected: []string{},
+		},
+		{
+			text:     "echo --msg hi",
+			expected: []string{"echo", "--msg", "hi"},
+		},
+		{
+			text:     "  echo   --msg\t'hello world'  ",
+			expected: []string{"echo", "--msg", "hello world"},
+		},
+		{
+			text:     `echo --msg "say \"hi\" \\ ok"`,
+			expected: []string{"echo", "--msg", `say "hi" \ ok`},
+		},
+		{
+			text:     `echo hello\ world ''`,
+			expected: []string{"echo", "hello world", ""},
+		},
+		{
+			text:     "echo --msg x#y # a comment\n--other z",
+			expected: []string{"echo", "--msg", "x#y", "--other", "z"},
+		},
+		{
+			text: "echo 'oops",
+			err:  ErrUnclosedQuote,
+		},
+		{
+			text: `echo "oops`,
+			err:  ErrUnclosedQuote,
+		},
+	}
+
+	for _, test := range tests {
+		actual, err := SplitArgs(test.text)
+		if err != test.err {
+			t.Errorf("Split %q expected error %v but got %v", test.text, test.err, err)
+		} else if err == nil && strings.Join(actual, "|") != strings.Join(test.expected, "|") || len(actual) != len(test.expected) {
+			t.Errorf("Split %q expected %q but got %q", test.text, test.expected, actual)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{a: "", b: "", expected: 0},
+		{a: "abc", b: "", expected: 3},
+		{a: "", b: "abc", expected: 3},
+		{a: "message", b: "message", expected: 0},
+		{a: "mesage", b: "message", expected: 1},
+		{a: "kitten", b: "sitting", expected: 3},
+	}
+
+	for _, test := range tests {
+		actual := EditDistance(test.a, test.b)
+		if actual != test.expected {
+			t.Errorf("Distance from %s to %s expected %d but got %d", test.a, test.b, test.expected, actual)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		input      string
+		candidates []string
+		expected   []string
+	}{
+		{
+			input:      "--mesage",
+			candidates: []string{"--message", "--name", "--age"},
+			expected:   []string{"--message"},
+		},
+		{
+			input:      "--nme",
+			candidates: []string{"--message", "--name", "--age"},
+			expected:   []string{"--name"},
+		},
+		{
+			input:      "--movies-1-titel",
+			candidates: []string{"--movies-1-title", "--movies-1-rating"},
+			expected:   []string{"--movies-1-title"},
+		},
+		{
+			input:      "--zzz",
+			candidates: []string{"--message", "--name", "--age"},
+			expected:   []string{},
+		},
+	}
+
+	for _, test := range tests {
+		actual := Suggest(test.input, test.candidates)
+		if strings.Join(actual, " ") != strings.Join(test.expected, " ") {
+			t.Errorf("Suggest %s expected %v but got %v", test.input, test.expected, actual)
+		}
+	}
+}